@@ -2,21 +2,37 @@ package cache
 
 import (
 	"context"
+	"log/slog"
+	"reflect"
+	"sync"
 	"time"
 
 	"github.com/qoinlyid/qore"
 	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
 )
 
 // Instance defines Cache dependency singleton.
 type Instance struct {
 	// Define dependency singleton here.
-	client redis.UniversalClient
+	store        Store
+	codec        Codec
+	keyring      *keyring
+	metrics      Metrics
+	tracing      *tracing
+	interceptors []Interceptor
+	logger       *slog.Logger
+	local        *localCache
+	invalidation *invalidation
 
 	// Private field.
+	plugged    bool // Whether the store is plugged through SetStore, it is kept across Close & Open.
+	id         string
 	cfg        *Config
 	startTime  time.Time
-	clustering bool
+	stats      stats
+	flight     singleflight.Group
+	refreshing sync.Map
 	*instanceGen
 }
 
@@ -24,6 +40,7 @@ type Instance struct {
 func New() *Instance {
 	config := loadConfig()
 	instance := &Instance{
+		id:          newInstanceID(),
 		cfg:         config,
 		instanceGen: &instanceGen{priority: config.DependencyPriority},
 	}
@@ -37,12 +54,12 @@ func (i *Instance) HealthCheck(ctx context.Context) *qore.DependencyStats {
 		UptimeSeconds: uptime.Seconds(),
 		UptimeHuman:   uptime.String(),
 	}
-	if i.client == nil {
+	if i.store == nil {
 		return stats
 	}
 
 	start := time.Now()
-	ping, err := i.client.Ping(ctx).Result()
+	ping, err := i.store.Ping(ctx)
 	latency := time.Since(start)
 	if err != nil {
 		stats.PINGResponse = err.Error()
@@ -63,6 +80,10 @@ func (i *Instance) Open() error {
 
 	// Set another instance field.
 	i.startTime = time.Now()
+	if i.logger != nil {
+		i.logger.LogAttrs(context.Background(), slog.LevelInfo, "cache opened",
+			slog.String("namespace", i.cfg.Namespace), slog.String("driver", i.cfg.Driver))
+	}
 
 	// Return.
 	return nil
@@ -71,16 +92,89 @@ func (i *Instance) Open() error {
 // Close an backend connection or destruct the dependency.
 func (i *Instance) Close() error {
 	// Close connection.
-	if i.client == nil {
+	i.stopInvalidation()
+	if pool, ok := i.metrics.(poolObserver); ok {
+		pool.forgetPool(i)
+	}
+	if i.local != nil {
+		i.local.close()
+		i.local = nil
+	}
+	if i.store == nil {
 		return nil
 	}
-	return i.client.Close()
+	err := i.store.Close()
+	if !i.plugged {
+		// The next Open opens a new store from the config.
+		i.store = nil
+	}
+	if i.logger != nil {
+		attrs := []slog.Attr{slog.String("namespace", i.cfg.Namespace), slog.Duration("uptime", time.Since(i.startTime))}
+		if err != nil {
+			attrs = append(attrs, slog.String("error", err.Error()))
+		}
+		i.logger.LogAttrs(context.Background(), slog.LevelInfo, "cache closed", attrs...)
+	}
+	return err
+}
+
+// SetStore plugs a custom backend store into the instance.
+// It must be called before Open, the plugged store will be used instead of
+// opening redis connection from the config.
+//
+//	cache := cache.New().SetStore(myStore)
+//	if err := cache.Open(); err != nil {
+//		log.Fatal(err)
+//	}
+func (i *Instance) SetStore(store Store) *Instance {
+	// Typed nil store (e.g. nil *redisStore) must not be stored, the non-nil interface hides it from validateStore.
+	if v := reflect.ValueOf(store); v.Kind() == reflect.Ptr && v.IsNil() {
+		store = nil
+	}
+	i.store = store
+	i.plugged = store != nil
+	return i
+}
+
+// SetCodec plugs the codec used to encode & decode the values, it overrides the config Codec.
+//
+//	cache := cache.New().SetCodec(cache.JSONCodec)
+func (i *Instance) SetCodec(codec Codec) *Instance {
+	i.codec = codec
+	return i
+}
+
+// SetMetrics plugs the metrics hook receiving the measurement of every cache operation.
+// The go-redis pool stats are also exported when the hook is the PrometheusMetrics.
+//
+//	metrics := cache.NewPrometheusMetrics(cache.PrometheusOptions{})
+//	prometheus.MustRegister(metrics)
+//	cache := cache.New().SetMetrics(metrics)
+func (i *Instance) SetMetrics(metrics Metrics) *Instance {
+	i.metrics = metrics
+	if pool, ok := metrics.(poolObserver); ok {
+		pool.observePool(i)
+	}
+	return i
+}
+
+// Store returns the backend store used by the instance.
+func (i *Instance) Store() (Store, error) {
+	if err := i.validateStore(); err != nil {
+		return nil, err
+	}
+	return i.store, nil
 }
 
 // Client returns redis client interface.
+// ErrClientNil is returned if the backend store is not redis.
 func (i *Instance) Client() (redis.UniversalClient, error) {
-	if err := i.validateClient(); err != nil {
+	if err := i.validateStore(); err != nil {
 		return nil, err
 	}
-	return i.client, nil
+	rs, ok := i.store.(*redisStore)
+	if !ok {
+		return nil, ErrClientNil
+	}
+	return rs.client, nil
 }
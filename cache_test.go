@@ -3,6 +3,7 @@ package cache
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/qoinlyid/qore"
 	"github.com/stretchr/testify/assert"
@@ -63,3 +64,56 @@ func TestClose(t *testing.T) {
 	err = i.Close()
 	assert.NoError(t, err, "Close must be no error")
 }
+
+func TestCloseReopen(t *testing.T) {
+	i := newLocalTest(t)
+	closed := i.store
+	assert.NoError(t, i.Close(), "Close must be no error")
+	assert.NoError(t, i.Open(), "Reopen must be no error")
+	assert.NotSame(t, closed, i.store, "Reopen must open a new store")
+	waitInvalidation(t, i)
+
+	_, err := i.Set(t.Context(), testKey).Put(testValue)
+	assert.NoError(t, err, "Put after reopen must be no error")
+	var val string
+	assert.NoError(t, i.Get(t.Context(), testKey).Pull(&val), "Pull after reopen must be no error")
+	assert.Equal(t, testValue, val, fmt.Sprintf("Value should be %s", testValue))
+
+	// The invalidation of another instance drops the local copy.
+	key := i.cfg.Namespace + DefaultKeySeparator + testKey
+	_, ok := i.local.get(key)
+	assert.True(t, ok, "Value must be cached locally")
+	i.store.(Notifier).Publish(t.Context(), i.invalidationChannel(), appendInvalidation(nil, "other", key))
+	assert.Eventually(t, func() bool {
+		_, ok := i.local.get(key)
+		return !ok
+	}, time.Second, time.Millisecond, "Invalidation must work after reopen")
+}
+
+func TestSetStoreTypedNil(t *testing.T) {
+	var store *redisStore
+	i := New().SetStore(store)
+	assert.Nil(t, i.store, "Typed nil store must not be stored")
+	assert.NotPanics(t, func() { i.Close() }, "Close must not panic")
+}
+
+func TestSetStore(t *testing.T) {
+	t.Setenv(qore.CONFIG_USED_KEY, "./.env")
+	store, err := newRedisStore(New().cfg)
+	if err != nil {
+		t.Skipf("Redis is not configured: %s", err)
+	}
+	if _, err := store.Ping(t.Context()); err != nil {
+		store.Close()
+		t.Skipf("Redis is not available: %s", err)
+	}
+
+	i := New().SetStore(store)
+	err = i.Open()
+	defer i.Close()
+	assert.NoError(t, err, "Open must be no error")
+
+	got, err := i.Store()
+	assert.NoError(t, err, "Store must be no error")
+	assert.Same(t, store, got, "Store must be the plugged store")
+}
@@ -0,0 +1,134 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Codec encodes the values into the stored bytes & decodes them back.
+type Codec interface {
+	// Name returns the codec name, it is the value of the config Codec.
+	Name() string
+
+	// Marshal returns the encoded bytes of the value.
+	Marshal(val any) ([]byte, error)
+
+	// Unmarshal parses the encoded bytes to the out, out must be a non-nil pointer.
+	Unmarshal(data []byte, out any) error
+}
+
+// Bundled codec.
+var (
+	// DefaultCodec stores the primitives as text & the other values as msgpack.
+	DefaultCodec Codec = defaultCodec{}
+
+	// JSONCodec stores the values as JSON, readable by the services in the other languages.
+	JSONCodec Codec = jsonCodec{}
+
+	// MsgpackCodec stores all values, including the primitives, as msgpack.
+	MsgpackCodec Codec = msgpackCodec{}
+
+	// GobCodec stores the values as gob, only readable by Go services.
+	GobCodec Codec = gobCodec{}
+
+	// RawCodec stores string & []byte values as is, the other types are rejected.
+	RawCodec Codec = rawCodec{}
+)
+
+// codecByName returns the bundled codec of the name.
+func codecByName(name string) (Codec, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", CodecDefault:
+		return DefaultCodec, nil
+	case CodecJSON:
+		return JSONCodec, nil
+	case CodecMsgpack:
+		return MsgpackCodec, nil
+	case CodecGob:
+		return GobCodec, nil
+	case CodecRaw:
+		return RawCodec, nil
+	case CodecProto:
+		return ProtoCodec, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownCodec, name)
+	}
+}
+
+// valueCodec returns the codec of the operation, the per call codec takes precedence over the instance codec.
+func (i *Instance) valueCodec(codec Codec) Codec {
+	switch {
+	case codec != nil:
+		return codec
+	case i.codec != nil:
+		return i.codec
+	default:
+		return DefaultCodec
+	}
+}
+
+type defaultCodec struct{}
+
+func (defaultCodec) Name() string                         { return CodecDefault }
+func (defaultCodec) Marshal(val any) ([]byte, error)      { return encoder(val) }
+func (defaultCodec) Unmarshal(data []byte, out any) error { return decoder(data, out) }
+
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string                         { return CodecJSON }
+func (jsonCodec) Marshal(val any) ([]byte, error)      { return json.Marshal(val) }
+func (jsonCodec) Unmarshal(data []byte, out any) error { return json.Unmarshal(data, out) }
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) Name() string                         { return CodecMsgpack }
+func (msgpackCodec) Marshal(val any) ([]byte, error)      { return msgpack.Marshal(val) }
+func (msgpackCodec) Unmarshal(data []byte, out any) error { return msgpack.Unmarshal(data, out) }
+
+type gobCodec struct{}
+
+func (gobCodec) Name() string { return CodecGob }
+
+func (gobCodec) Marshal(val any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(val); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobCodec) Unmarshal(data []byte, out any) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(out)
+}
+
+type rawCodec struct{}
+
+func (rawCodec) Name() string { return CodecRaw }
+
+func (rawCodec) Marshal(val any) ([]byte, error) {
+	switch v := val.(type) {
+	case string:
+		return []byte(v), nil
+	case []byte:
+		return v, nil
+	default:
+		return nil, fmt.Errorf("raw codec cannot encode %T, only string & []byte are supported", val)
+	}
+}
+
+func (rawCodec) Unmarshal(data []byte, out any) error {
+	switch v := out.(type) {
+	case *string:
+		*v = string(data)
+	case *[]byte:
+		*v = append((*v)[:0], data...)
+	default:
+		return fmt.Errorf("raw codec cannot decode to %T, only *string & *[]byte are supported", out)
+	}
+	return nil
+}
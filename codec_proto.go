@@ -0,0 +1,54 @@
+package cache
+
+import (
+	"reflect"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// ProtoCodec stores the proto.Message values as binary protobuf, the other values fall back to DefaultCodec.
+var ProtoCodec Codec = NewProtoCodec(nil)
+
+// NewProtoCodec creates protobuf codec with the fallback codec for the non proto.Message values,
+// nil fallback means DefaultCodec. The out can be either the message (e.g. *pb.User) or the pointer
+// to the message pointer (e.g. **pb.User) which is allocated.
+//
+//	codec := cache.NewProtoCodec(cache.JSONCodec)
+func NewProtoCodec(fallback Codec) Codec {
+	if fallback == nil {
+		fallback = DefaultCodec
+	}
+	return protoCodec{fallback: fallback}
+}
+
+type protoCodec struct {
+	fallback Codec
+}
+
+func (protoCodec) Name() string { return CodecProto }
+
+func (c protoCodec) Marshal(val any) ([]byte, error) {
+	if m, ok := val.(proto.Message); ok {
+		return proto.Marshal(m)
+	}
+	return c.fallback.Marshal(val)
+}
+
+func (c protoCodec) Unmarshal(data []byte, out any) error {
+	if m, ok := out.(proto.Message); ok {
+		return proto.Unmarshal(data, m)
+	}
+
+	// Pointer to the message pointer.
+	ov := reflect.ValueOf(out)
+	if ov.Kind() == reflect.Ptr && !ov.IsNil() && ov.Elem().Kind() == reflect.Ptr {
+		if m, ok := reflect.New(ov.Elem().Type().Elem()).Interface().(proto.Message); ok {
+			if err := proto.Unmarshal(data, m); err != nil {
+				return err
+			}
+			ov.Elem().Set(reflect.ValueOf(m))
+			return nil
+		}
+	}
+	return c.fallback.Unmarshal(data, out)
+}
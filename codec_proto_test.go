@@ -0,0 +1,51 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestProtoCodecMessage(t *testing.T) {
+	expected := timestamppb.New(time.Unix(1700000000, 42))
+	b, err := ProtoCodec.Marshal(expected)
+	assert.NoError(t, err, "Marshal message must be no error")
+	wire, _ := proto.Marshal(expected)
+	assert.Equal(t, wire, b, "Message must be stored as binary protobuf")
+
+	out := &timestamppb.Timestamp{}
+	assert.NoError(t, ProtoCodec.Unmarshal(b, out), "Unmarshal to message must be no error")
+	assert.True(t, proto.Equal(expected, out), "Message must be equal")
+
+	var ptr *timestamppb.Timestamp
+	assert.NoError(t, ProtoCodec.Unmarshal(b, &ptr), "Unmarshal to message pointer must be no error")
+	assert.True(t, proto.Equal(expected, ptr), "Allocated message must be equal")
+}
+
+func TestProtoCodecFallback(t *testing.T) {
+	b, err := ProtoCodec.Marshal(typedUser{ID: 1, Name: "John"})
+	assert.NoError(t, err, "Marshal non-message must be no error")
+	var out typedUser
+	assert.NoError(t, ProtoCodec.Unmarshal(b, &out), "Unmarshal non-message must be no error")
+	assert.Equal(t, typedUser{ID: 1, Name: "John"}, out, "Non-message must use the fallback codec")
+
+	b, err = NewProtoCodec(JSONCodec).Marshal(testValue)
+	assert.NoError(t, err, "Marshal with the JSON fallback must be no error")
+	assert.Equal(t, `"`+testValue+`"`, string(b), "Non-message must use the JSON fallback")
+}
+
+func TestProtoCodecTyped(t *testing.T) {
+	i := newMemoryTest(t).SetCodec(ProtoCodec)
+	names := For[*wrapperspb.StringValue](i, "name")
+	err := names.Set(t.Context(), "1", wrapperspb.String(testValue), time.Minute)
+	assert.NoError(t, err, "Set must be no error")
+
+	val, found, err := names.Get(t.Context(), "1")
+	assert.NoError(t, err, "Get must be no error")
+	assert.True(t, found, "Key must be found")
+	assert.Equal(t, testValue, val.GetValue(), "Message value must be equal")
+}
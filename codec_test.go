@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/qoinlyid/qore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCodecRoundTrip(t *testing.T) {
+	expected := typedUser{ID: 1, Name: "John"}
+	for _, codec := range []Codec{DefaultCodec, JSONCodec, MsgpackCodec, GobCodec} {
+		b, err := codec.Marshal(expected)
+		assert.NoError(t, err, fmt.Sprintf("Marshal of %s codec must be no error", codec.Name()))
+		var out typedUser
+		err = codec.Unmarshal(b, &out)
+		assert.NoError(t, err, fmt.Sprintf("Unmarshal of %s codec must be no error", codec.Name()))
+		assert.Equal(t, expected, out, fmt.Sprintf("Value of %s codec must be equal", codec.Name()))
+	}
+}
+
+func TestRawCodec(t *testing.T) {
+	b, err := RawCodec.Marshal(testValue)
+	assert.NoError(t, err, "Marshal string must be no error")
+	var out string
+	assert.NoError(t, RawCodec.Unmarshal(b, &out), "Unmarshal string must be no error")
+	assert.Equal(t, testValue, out, fmt.Sprintf("Value should be %s", testValue))
+
+	_, err = RawCodec.Marshal(1)
+	assert.Error(t, err, "Marshal int must be error")
+	var n int
+	assert.Error(t, RawCodec.Unmarshal(b, &n), "Unmarshal to int must be error")
+}
+
+func TestCodecByName(t *testing.T) {
+	for _, name := range []string{CodecDefault, CodecJSON, CodecMsgpack, CodecGob, CodecRaw, CodecProto} {
+		codec, err := codecByName(name)
+		assert.NoError(t, err, fmt.Sprintf("Codec %s must be found", name))
+		assert.Equal(t, name, codec.Name(), fmt.Sprintf("Codec name should be %s", name))
+	}
+	_, err := codecByName("xml")
+	assert.ErrorIs(t, err, ErrUnknownCodec, "Unknown codec must be ErrUnknownCodec")
+}
+
+func TestCodecFromConfig(t *testing.T) {
+	t.Setenv("CACHE_CODEC", CodecJSON)
+	i := newMemoryTest(t)
+	assert.Equal(t, JSONCodec, i.codec, "Instance codec should be JSON")
+
+	_, err := i.Set(t.Context(), testKey).Put(typedUser{ID: 1, Name: "John"})
+	assert.NoError(t, err, "Put must be no error")
+	b, _ := i.store.Get(t.Context(), i.cfg.Namespace+DefaultKeySeparator+testKey)
+	assert.JSONEq(t, `{"ID":1,"Name":"John"}`, string(b), "Value must be stored as JSON")
+}
+
+func TestCodecUnknownFromConfig(t *testing.T) {
+	t.Setenv(qore.CONFIG_USED_KEY, "OS")
+	t.Setenv("CACHE_DRIVER", DriverMemory)
+	t.Setenv("CACHE_CODEC", "xml")
+	err := New().Open()
+	assert.ErrorIs(t, err, ErrUnknownCodec, "Open with unknown codec must be error")
+}
+
+func TestCodecPerCall(t *testing.T) {
+	i := newMemoryTest(t).SetCodec(GobCodec)
+	_, err := i.Set(t.Context(), testKey).SetCodec(JSONCodec).SetTTL(time.Minute).Put(testValue)
+	assert.NoError(t, err, "Put must be no error")
+	b, _ := i.store.Get(t.Context(), i.cfg.Namespace+DefaultKeySeparator+testKey)
+	assert.Equal(t, `"`+testValue+`"`, string(b), "Per call codec must override the instance codec")
+
+	var out string
+	err = i.Get(t.Context(), testKey).SetCodec(JSONCodec).Pull(&out)
+	assert.NoError(t, err, "Pull must be no error")
+	assert.Equal(t, testValue, out, fmt.Sprintf("Value should be %s", testValue))
+	err = i.Get(t.Context(), testKey).Pull(&out)
+	assert.ErrorIs(t, err, ErrDecode, "Pull with the other codec must be ErrDecode")
+}
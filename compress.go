@@ -0,0 +1,129 @@
+package cache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/klauspost/compress/s2"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression algorithm id, stored right after the compressed frame header.
+const (
+	compressGzip byte = iota + 1
+	compressZstd
+	compressS2
+	compressSnappy
+)
+
+// decompressMaxSize defines max size of the decompressed value, so a corrupted or hostile value
+// cannot expand without bound. It is the redis max string size.
+const decompressMaxSize = 512 << 20
+
+// zstd encoder & decoder are safe for concurrent EncodeAll & DecodeAll, so they are shared.
+var (
+	zstdEncoder = sync.OnceValue(func() *zstd.Encoder {
+		enc, _ := zstd.NewWriter(nil)
+		return enc
+	})
+	zstdDecoder = sync.OnceValue(func() *zstd.Decoder {
+		dec, _ := zstd.NewReader(nil, zstd.WithDecoderMaxMemory(decompressMaxSize))
+		return dec
+	})
+)
+
+// validCompression reports whether the compression algorithm is supported.
+func validCompression(algo string) bool {
+	switch algo {
+	case "", CompressionNone, CompressionGzip, CompressionZstd, CompressionS2, CompressionSnappy:
+		return true
+	default:
+		return false
+	}
+}
+
+// compress returns the compressed frame of b.
+func compress(algo string, b []byte) ([]byte, error) {
+	header := []byte{frameMagic, frameCompressed, 0}
+	switch algo {
+	case CompressionGzip:
+		header[2] = compressGzip
+		buf := bytes.NewBuffer(header)
+		w := gzip.NewWriter(buf)
+		if _, err := w.Write(b); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case CompressionZstd:
+		header[2] = compressZstd
+		return zstdEncoder().EncodeAll(b, header), nil
+	case CompressionS2:
+		header[2] = compressS2
+		return append(header, s2.Encode(nil, b)...), nil
+	case CompressionSnappy:
+		header[2] = compressSnappy
+		return append(header, s2.EncodeSnappy(nil, b)...), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownCompression, algo)
+	}
+}
+
+// decompress returns the decompressed value of the compressed frame body, the algorithm id followed by the data.
+// ErrDecode is returned when the decompressed value exceeds limit bytes.
+func decompress(b []byte, limit int) ([]byte, error) {
+	if len(b) == 0 {
+		return nil, errInvalidFrame
+	}
+	var (
+		d   []byte
+		err error
+	)
+	switch b[0] {
+	case compressGzip:
+		r, err := gzip.NewReader(bytes.NewReader(b[1:]))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		d, err = io.ReadAll(io.LimitReader(r, int64(limit)+1))
+		if err != nil {
+			return nil, err
+		}
+	case compressZstd:
+		d, err = zstdDecoder().DecodeAll(b[1:], nil)
+		if errors.Is(err, zstd.ErrDecoderSizeExceeded) {
+			return nil, errDecompressLimit(limit)
+		}
+		if err != nil {
+			return nil, err
+		}
+	case compressS2, compressSnappy:
+		// S2 decoder also decodes snappy blocks, the decoded length is known before decoding.
+		n, err := s2.DecodedLen(b[1:])
+		if err != nil {
+			return nil, err
+		}
+		if n > limit {
+			return nil, errDecompressLimit(limit)
+		}
+		return s2.Decode(nil, b[1:])
+	default:
+		return nil, errInvalidFrame
+	}
+	if len(d) > limit {
+		return nil, errDecompressLimit(limit)
+	}
+	return d, nil
+}
+
+// errDecompressLimit returns the error of the decompressed value exceeding the limit.
+func errDecompressLimit(limit int) error {
+	return fmt.Errorf("%w: decompressed value exceeds %d bytes", ErrDecode, limit)
+}
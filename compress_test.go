@@ -0,0 +1,112 @@
+package cache
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qoinlyid/qore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompressRoundTrip(t *testing.T) {
+	payload := bytes.Repeat([]byte(testValue), 200)
+	for _, algo := range []string{CompressionGzip, CompressionZstd, CompressionS2, CompressionSnappy} {
+		b, err := encodeFrame(payload, frameOptions{compression: algo})
+		assert.NoError(t, err, fmt.Sprintf("Encode %s frame must be no error", algo))
+		assert.Equal(t, []byte{frameMagic, frameCompressed}, b[:frameHeaderLen], fmt.Sprintf("Value must be %s compressed", algo))
+		assert.Less(t, len(b), len(payload), fmt.Sprintf("Compressed %s value must be smaller", algo))
+
+		decoded, _, err := decodeFrame(b, frameOptions{})
+		assert.NoError(t, err, fmt.Sprintf("Decode %s frame must be no error", algo))
+		assert.Equal(t, payload, decoded, fmt.Sprintf("Decompressed %s value must be equal", algo))
+	}
+
+	_, err := encodeFrame(payload, frameOptions{compression: "lz4"})
+	assert.ErrorIs(t, err, ErrUnknownCompression, "Unknown compression must be error")
+}
+
+func TestCompressThreshold(t *testing.T) {
+	payload := bytes.Repeat([]byte("a"), 100)
+	b, _ := encodeFrame(payload, frameOptions{compression: CompressionZstd, threshold: 101})
+	assert.Equal(t, payload, b, "Value below the threshold must not be compressed")
+
+	b, _ = encodeFrame([]byte("ab"), frameOptions{compression: CompressionGzip})
+	assert.Equal(t, []byte("ab"), b, "Value must not be compressed when it does not pay off")
+}
+
+func TestCompressEscapedAndMeta(t *testing.T) {
+	raw := append([]byte{frameMagic, frameMeta}, bytes.Repeat([]byte("x"), 100)...)
+	meta := &valueMeta{softExpire: 1, delta: 2}
+	b, err := encodeFrame(raw, frameOptions{meta: meta, compression: CompressionS2})
+	assert.NoError(t, err, "Encode frame must be no error")
+
+	payload, decodedMeta, err := decodeFrame(b, frameOptions{})
+	assert.NoError(t, err, "Decode frame must be no error")
+	assert.Equal(t, meta, decodedMeta, "Meta must be kept outside the compression")
+	assert.Equal(t, raw, payload, "Escaped raw value must be decompressed as is")
+}
+
+func TestCompressionPerCall(t *testing.T) {
+	i := newMemoryTest(t)
+	val := strings.Repeat(testValue, 500)
+	_, err := i.Set(t.Context(), testKey).SetCompression(CompressionZstd).SetTTL(time.Minute).Put(val)
+	assert.NoError(t, err, "Put must be no error")
+
+	b, _ := i.store.Get(t.Context(), i.cfg.Namespace+DefaultKeySeparator+testKey)
+	assert.Less(t, len(b), len(val), "Value must be stored compressed")
+	var out string
+	err = i.Get(t.Context(), testKey).Pull(&out)
+	assert.NoError(t, err, "Pull must be no error")
+	assert.Equal(t, val, out, "Compressed value must be detected on read")
+
+	// Uncompressed value stored before enabling the compression remains readable.
+	i.store.Set(t.Context(), i.cfg.Namespace+DefaultKeySeparator+testKey, []byte(testValue), time.Minute)
+	err = i.Get(t.Context(), testKey).Pull(&out)
+	assert.NoError(t, err, "Pull must be no error")
+	assert.Equal(t, testValue, out, "Uncompressed value must be readable")
+}
+
+func TestCompressionFromConfig(t *testing.T) {
+	t.Setenv("CACHE_COMPRESSION", CompressionGzip)
+	t.Setenv("CACHE_COMPRESSION_THRESHOLD", "10")
+	i := newMemoryTest(t)
+	assert.Equal(t, CompressionGzip, i.cfg.Compression, "Compression should be gzip")
+	assert.Equal(t, 10, i.cfg.CompressionThreshold, "Compression threshold should be 10")
+
+	items := []Item{{Key: "1", Value: strings.Repeat("a", 100)}, {Key: "2", Value: "a"}}
+	_, err := i.SetMany(t.Context()).PutMany(items...)
+	assert.NoError(t, err, "PutMany must be no error")
+	b, _ := i.store.Get(t.Context(), i.cfg.Namespace+DefaultKeySeparator+"1")
+	assert.Equal(t, []byte{frameMagic, frameCompressed, compressGzip}, b[:3], "Value must be gzip compressed")
+
+	var vals map[string]string
+	_, err = i.GetMany(t.Context(), []string{"1", "2"}).Pull(&vals)
+	assert.NoError(t, err, "GetMany must be no error")
+	assert.Equal(t, strings.Repeat("a", 100), vals["1"], "Compressed value must be decompressed")
+	assert.Equal(t, "a", vals["2"], "Small value must be stored as is")
+}
+
+func TestCompressionUnknownFromConfig(t *testing.T) {
+	t.Setenv(qore.CONFIG_USED_KEY, "OS")
+	t.Setenv("CACHE_DRIVER", DriverMemory)
+	t.Setenv("CACHE_COMPRESSION", "lz4")
+	err := New().Open()
+	assert.ErrorIs(t, err, ErrUnknownCompression, "Open with unknown compression must be error")
+}
+
+func TestDecompressLimit(t *testing.T) {
+	payload := bytes.Repeat([]byte(testValue), 200)
+	for _, algo := range []string{CompressionGzip, CompressionZstd, CompressionS2, CompressionSnappy} {
+		b, err := encodeFrame(payload, frameOptions{compression: algo})
+		assert.NoError(t, err, fmt.Sprintf("Encode %s frame must be no error", algo))
+
+		_, err = decompress(b[frameHeaderLen:], len(payload)-1)
+		assert.ErrorIs(t, err, ErrDecode, fmt.Sprintf("Decompressed %s value over the limit must be error", algo))
+		decoded, err := decompress(b[frameHeaderLen:], len(payload))
+		assert.NoError(t, err, fmt.Sprintf("Decompressed %s value within the limit must be no error", algo))
+		assert.Equal(t, payload, decoded, fmt.Sprintf("Decompressed %s value must be equal", algo))
+	}
+}
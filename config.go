@@ -3,10 +3,12 @@ package cache
 import (
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
+	"time"
 
 	"github.com/qoinlyid/qore"
 	"github.com/spf13/viper"
@@ -17,6 +19,53 @@ type Config struct {
 	// DependencyPriority defines priority of cache dependency.
 	DependencyPriority int `json:"CACHE_DEPENDENCY_PRIORITY" mapstructure:"CACHE_DEPENDENCY_PRIORITY"`
 
+	// Driver defines cache backend driver, one of "redis" or "memory". Default is "redis".
+	Driver string `json:"CACHE_DRIVER" mapstructure:"CACHE_DRIVER"`
+
+	// Codec defines how the values are encoded, one of "default", "json", "msgpack", "gob", "raw" or "proto".
+	// The default codec stores the primitives as text & the other values as msgpack, the proto codec stores
+	// the proto.Message values as binary protobuf & falls back to the default codec for the other values.
+	Codec string `json:"CACHE_CODEC" mapstructure:"CACHE_CODEC"`
+
+	// Compression defines how the values above CompressionThreshold are compressed, one of "none", "gzip",
+	// "zstd", "s2" or "snappy". Default is "none", the compressed values are detected on read so the values
+	// stored before the change remain readable.
+	Compression string `json:"CACHE_COMPRESSION" mapstructure:"CACHE_COMPRESSION"`
+
+	// CompressionThreshold defines min size in bytes of the encoded value to be compressed. Default is 1024.
+	CompressionThreshold int `json:"CACHE_COMPRESSION_THRESHOLD" mapstructure:"CACHE_COMPRESSION_THRESHOLD"`
+
+	// EncryptionKeys defines the AES-GCM keys encrypting the stored values, a comma separated list of
+	// `id:base64key` with 16, 24 or 32 bytes key. The first key encrypts the new writes, the others only
+	// decrypt the values written before the rotation. Empty means the values are stored unencrypted.
+	EncryptionKeys string `json:"CACHE_ENCRYPTION_KEYS" mapstructure:"CACHE_ENCRYPTION_KEYS"`
+
+	// EncryptionKeysFile defines path of the file holding more `id:base64key` keys, one per line,
+	// appended after EncryptionKeys.
+	EncryptionKeysFile string `json:"CACHE_ENCRYPTION_KEYS_FILE" mapstructure:"CACHE_ENCRYPTION_KEYS_FILE"`
+
+	// Envelope wraps every stored value in the versioned envelope carrying the codec id, SchemaVersion
+	// & the checksum. The value with the other codec, the other version, a bad checksum or no envelope
+	// is read as a miss. Default is false: enabling it turns every value stored without the envelope,
+	// also by the instances not upgraded yet, into a miss, so the whole cache is reloaded once.
+	// Enable it on all instances at once, ideally with a fresh namespace.
+	Envelope bool `json:"CACHE_ENVELOPE" mapstructure:"CACHE_ENVELOPE"`
+
+	// SchemaVersion defines the version of the stored values, bump it when the cached types change.
+	// A non-zero version enables the envelope.
+	SchemaVersion uint32 `json:"CACHE_SCHEMA_VERSION" mapstructure:"CACHE_SCHEMA_VERSION"`
+
+	// EnvelopeDeleteInvalid deletes the value read as a miss because of the envelope. Default is false.
+	EnvelopeDeleteInvalid bool `json:"CACHE_ENVELOPE_DELETE_INVALID" mapstructure:"CACHE_ENVELOPE_DELETE_INVALID"`
+
+	// SlowThreshold defines min duration of the operation logged as slow, zero disables the slow log.
+	// It needs the logger plugged through SetLogger.
+	SlowThreshold time.Duration `json:"CACHE_SLOW_THRESHOLD" mapstructure:"CACHE_SLOW_THRESHOLD"`
+
+	// LogKeys defines how the keys are logged, one of "prefix" (only the key prefix), "hash"
+	// (the key prefix & the short SHA-256 of the key) or "full" (the raw key). Default is "prefix".
+	LogKeys string `json:"CACHE_LOG_KEYS" mapstructure:"CACHE_LOG_KEYS"`
+
 	// Namespace defines cache key prefix that always be used.
 	Namespace string `json:"CACHE_NAMESPACE" mapstructure:"CACHE_NAMESPACE"`
 
@@ -47,17 +96,74 @@ type Config struct {
 
 	// SentinelCluster defines redis sentinel backend is using cluster mode.
 	SentinelCluster bool `json:"CACHE_SENTINEL_CLUSTER" mapstructure:"CACHE_SENTINEL_CLUSTER"`
+
+	// MemoryMaxEntries defines max entries of the memory driver, the sampled least recently used entry
+	// is evicted when it is full. Zero means unlimited.
+	MemoryMaxEntries int `json:"CACHE_MEMORY_MAX_ENTRIES" mapstructure:"CACHE_MEMORY_MAX_ENTRIES"`
+
+	// MemoryCleanupInterval defines interval of the memory driver to remove expired entries.
+	MemoryCleanupInterval time.Duration `json:"CACHE_MEMORY_CLEANUP_INTERVAL" mapstructure:"CACHE_MEMORY_CLEANUP_INTERVAL"`
+
+	// LocalEnabled defines whether the in-process L1 tier is used in front of the backend store.
+	LocalEnabled bool `json:"CACHE_LOCAL_ENABLED" mapstructure:"CACHE_LOCAL_ENABLED"`
+
+	// LocalMaxEntries defines max entries of the L1 tier.
+	LocalMaxEntries int `json:"CACHE_LOCAL_MAX_ENTRIES" mapstructure:"CACHE_LOCAL_MAX_ENTRIES"`
+
+	// LocalTTL defines max time-to-live of the L1 tier entry, it bounds the staleness of the L1 tier.
+	LocalTTL time.Duration `json:"CACHE_LOCAL_TTL" mapstructure:"CACHE_LOCAL_TTL"`
+
+	// LocalPolicy defines eviction policy of the L1 tier, one of "lru" or "lfu". Default is "lru".
+	LocalPolicy string `json:"CACHE_LOCAL_POLICY" mapstructure:"CACHE_LOCAL_POLICY"`
+
+	// LocalSync defines how the L1 tier is kept coherent across instances, one of "none", "pubsub",
+	// "tracking" or "tracking-bcast". Default is "pubsub" when the L1 tier is enabled, otherwise "none".
+	// Set it to "pubsub" explicitly on the instance without L1 tier to publish invalidation to the other
+	// instances. The "tracking" modes use redis 6+ client-side caching (CLIENT TRACKING), in BCAST mode
+	// the server pushes invalidation for every key under the namespace instead of the keys read only.
+	// The tracking modes are not supported in cluster mode.
+	LocalSync string `json:"CACHE_LOCAL_SYNC" mapstructure:"CACHE_LOCAL_SYNC"`
+
+	// RememberLock defines whether Remember acquires a distributed lock in the backend store before
+	// calling the loader, so only one instance across the fleet loads the missing key while the others
+	// wait for the value. Concurrent Remember of the same key in the same process always share one loader.
+	RememberLock bool `json:"CACHE_REMEMBER_LOCK" mapstructure:"CACHE_REMEMBER_LOCK"`
+
+	// RememberLockTTL defines time-to-live of the Remember lock, it bounds how long the other instances
+	// wait when the lock holder dies. Default is 10 seconds.
+	RememberLockTTL time.Duration `json:"CACHE_REMEMBER_LOCK_TTL" mapstructure:"CACHE_REMEMBER_LOCK_TTL"`
+
+	// RememberLockWait defines how long Remember waits for the lock holder to store the value before
+	// calling the loader by itself. Default is 5 seconds.
+	RememberLockWait time.Duration `json:"CACHE_REMEMBER_LOCK_WAIT" mapstructure:"CACHE_REMEMBER_LOCK_WAIT"`
+
+	// NegativeTTL defines time-to-live of the tombstone stored by Remember when the loader returns
+	// ErrNotFound, it should be shorter than the value TTL. Default is 30 seconds.
+	NegativeTTL time.Duration `json:"CACHE_NEGATIVE_TTL" mapstructure:"CACHE_NEGATIVE_TTL"`
 }
 
 // Default config.
 var defaultConfig = &Config{
-	DependencyPriority: 10,
+	DependencyPriority:    10,
+	Driver:                DriverRedis,
+	Codec:                 CodecDefault,
+	Compression:           CompressionNone,
+	CompressionThreshold:  1024,
+	MemoryCleanupInterval: time.Minute,
+	LocalMaxEntries:       10000,
+	LocalTTL:              10 * time.Second,
+	LocalPolicy:           LocalPolicyLRU,
+	RememberLockTTL:       10 * time.Second,
+	RememberLockWait:      5 * time.Second,
+	NegativeTTL:           30 * time.Second,
+	LogKeys:               LogKeysPrefix,
 }
 
 // Load config.
 func loadConfig() *Config {
 	var e error
-	config := defaultConfig
+	config := new(Config)
+	*config = *defaultConfig
 
 	// Get used config from OS env.
 	configSource := os.Getenv(qore.CONFIG_USED_KEY)
@@ -66,6 +172,7 @@ func loadConfig() *Config {
 	}
 	viper.AutomaticEnv()
 	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	bindEnvs(config)
 
 	switch strings.ToUpper(configSource) {
 	case "OS":
@@ -87,6 +194,7 @@ func loadConfig() *Config {
 			}
 		case ".json", ".yml", ".yaml", ".toml":
 			viper.SetConfigFile(configSource)
+			viper.SetConfigType(strings.TrimPrefix(ext, "."))
 			if err := viper.ReadInConfig(); err != nil {
 				e = errors.Join(fmt.Errorf("failed to read config file %s: %w", configSource, err))
 			} else {
@@ -97,7 +205,7 @@ func loadConfig() *Config {
 		}
 	}
 	if e != nil {
-		log.Printf("dependency config - failed to load config: %s\n", e.Error())
+		slog.Warn("cache config - failed to load config", slog.String("source", configSource), slog.String("error", e.Error()))
 	}
 
 	// Config value modifier.
@@ -107,5 +215,71 @@ func loadConfig() *Config {
 	if qore.ValidationIsEmpty(config.Namespace) {
 		config.Namespace = DefaultNameSpace
 	}
+	config.Driver = strings.ToLower(strings.TrimSpace(config.Driver))
+	if qore.ValidationIsEmpty(config.Driver) {
+		config.Driver = defaultConfig.Driver
+	}
+	config.Codec = strings.ToLower(strings.TrimSpace(config.Codec))
+	if qore.ValidationIsEmpty(config.Codec) {
+		config.Codec = defaultConfig.Codec
+	}
+	config.Compression = strings.ToLower(strings.TrimSpace(config.Compression))
+	if qore.ValidationIsEmpty(config.Compression) {
+		config.Compression = defaultConfig.Compression
+	}
+	if config.CompressionThreshold <= 0 {
+		config.CompressionThreshold = defaultConfig.CompressionThreshold
+	}
+	config.EncryptionKeysFile = strings.TrimSpace(config.EncryptionKeysFile)
+	if config.MemoryCleanupInterval < 0 {
+		config.MemoryCleanupInterval = 0
+	}
+	if config.LocalMaxEntries <= 0 {
+		config.LocalMaxEntries = defaultConfig.LocalMaxEntries
+	}
+	if config.LocalTTL <= 0 {
+		config.LocalTTL = defaultConfig.LocalTTL
+	}
+	config.LocalPolicy = strings.ToLower(strings.TrimSpace(config.LocalPolicy))
+	if config.LocalPolicy != LocalPolicyLFU {
+		config.LocalPolicy = LocalPolicyLRU
+	}
+	config.LocalSync = strings.ToLower(strings.TrimSpace(config.LocalSync))
+	if qore.ValidationIsEmpty(config.LocalSync) {
+		config.LocalSync = LocalSyncNone
+		if config.LocalEnabled {
+			config.LocalSync = LocalSyncPubSub
+		}
+	}
+	if config.RememberLockTTL <= 0 {
+		config.RememberLockTTL = defaultConfig.RememberLockTTL
+	}
+	if config.RememberLockWait <= 0 {
+		config.RememberLockWait = defaultConfig.RememberLockWait
+	}
+	if config.NegativeTTL <= 0 {
+		config.NegativeTTL = defaultConfig.NegativeTTL
+	}
+	if config.SlowThreshold < 0 {
+		config.SlowThreshold = 0
+	}
+	config.LogKeys = strings.ToLower(strings.TrimSpace(config.LogKeys))
+	if config.LogKeys != LogKeysHash && config.LogKeys != LogKeysFull {
+		config.LogKeys = LogKeysPrefix
+	}
 	return config
 }
+
+// bindEnvs binds the config fields to OS env, so viper can unmarshal OS env value
+// even the key is absent in the config file or no config file is read.
+func bindEnvs(config any) {
+	t := reflect.TypeOf(config)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	for i := range t.NumField() {
+		if key := t.Field(i).Tag.Get("mapstructure"); !qore.ValidationIsEmpty(key) {
+			viper.BindEnv(key)
+		}
+	}
+}
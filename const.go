@@ -8,6 +8,71 @@ const (
 	DefaultKeySeparator = ":"
 )
 
+// Driver.
+const (
+	DriverRedis  = "redis"
+	DriverMemory = "memory"
+)
+
+// Local tier eviction policy.
+const (
+	LocalPolicyLRU = "lru"
+	LocalPolicyLFU = "lfu"
+)
+
+// Local tier synchronization.
+const (
+	LocalSyncNone          = "none"
+	LocalSyncPubSub        = "pubsub"
+	LocalSyncTracking      = "tracking"
+	LocalSyncTrackingBCAST = "tracking-bcast"
+	KeyInvalidation        = "invalidate"
+)
+
+// Codec.
+const (
+	CodecDefault = "default"
+	CodecJSON    = "json"
+	CodecMsgpack = "msgpack"
+	CodecGob     = "gob"
+	CodecRaw     = "raw"
+	CodecProto   = "proto"
+)
+
+// Compression.
+const (
+	CompressionNone   = "none"
+	CompressionGzip   = "gzip"
+	CompressionZstd   = "zstd"
+	CompressionS2     = "s2"
+	CompressionSnappy = "snappy"
+)
+
+// Log keys redaction.
+const (
+	LogKeysPrefix = "prefix"
+	LogKeysHash   = "hash"
+	LogKeysFull   = "full"
+)
+
+// Remember lock.
+const (
+	KeyLock = "lock"
+)
+
+// Operation.
+const (
+	OpGet       = "get"
+	OpSet       = "set"
+	OpDel       = "del"
+	OpHas       = "has"
+	OpScan      = "scan"
+	OpRateLimit = "ratelimit"
+	OpAcquire   = "acquire"
+	OpExtend    = "extend"
+	OpRelease   = "release"
+)
+
 // Numeric
 const (
 	DefaultTTL = time.Minute
@@ -17,3 +82,8 @@ const (
 const (
 	KeyRateLimit = "rate-limit"
 )
+
+// Semaphore.
+const (
+	KeySemaphore = "semaphore"
+)
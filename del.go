@@ -30,9 +30,11 @@ func (i *Instance) Delete(ctx context.Context, key string, prefix ...string) *de
 	if ctx == nil {
 		ctx, cancel = context.WithTimeout(context.Background(), time.Second)
 	}
+	var keyPrefix string
 	if len(prefix) > 0 {
 		if !qore.ValidationIsEmpty(prefix[0]) {
 			key = prefix[0] + DefaultKeySeparator + key
+			keyPrefix = prefix[0]
 		}
 	}
 
@@ -42,6 +44,7 @@ func (i *Instance) Delete(ctx context.Context, key string, prefix ...string) *de
 			ctx:    ctx,
 			cancel: cancel,
 			key:    key,
+			prefix: keyPrefix,
 		},
 		delFn: i.del,
 	}
@@ -0,0 +1,112 @@
+package cache
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/qoinlyid/qore"
+)
+
+// keyring holds the AES-GCM keys by the key id, the values are encrypted with the active key
+// & decrypted with the key of the id stored in the frame.
+type keyring struct {
+	active string
+	aeads  map[string]cipher.AEAD
+}
+
+// loadKeyring loads the encryption keys from the config EncryptionKeys then EncryptionKeysFile,
+// nil keyring is returned when no key is configured.
+func loadKeyring(cfg *Config) (*keyring, error) {
+	spec := cfg.EncryptionKeys
+	if !qore.ValidationIsEmpty(cfg.EncryptionKeysFile) {
+		b, err := os.ReadFile(cfg.EncryptionKeysFile)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrEncryptionKey, err)
+		}
+		spec += "," + string(b)
+	}
+	return parseKeyring(spec)
+}
+
+// parseKeyring parses the comma or newline separated `id:base64key` list, the first key is the active one.
+// The key must be 16, 24 or 32 bytes to select AES-128, AES-192 or AES-256.
+func parseKeyring(spec string) (*keyring, error) {
+	ring := &keyring{aeads: make(map[string]cipher.AEAD)}
+	for entry := range strings.FieldsFuncSeq(spec, func(r rune) bool { return r == ',' || r == '\n' }) {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, encoded, ok := strings.Cut(entry, ":")
+		if !ok || id == "" || len(id) > 255 {
+			return nil, fmt.Errorf("%w: entry must be id:base64key", ErrEncryptionKey)
+		}
+		if _, exists := ring.aeads[id]; exists {
+			return nil, fmt.Errorf("%w: duplicate key id %s", ErrEncryptionKey, id)
+		}
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil {
+			return nil, fmt.Errorf("%w: key %s: %w", ErrEncryptionKey, id, err)
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("%w: key %s: %w", ErrEncryptionKey, id, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("%w: key %s: %w", ErrEncryptionKey, id, err)
+		}
+		ring.aeads[id] = aead
+		if ring.active == "" {
+			ring.active = id
+		}
+	}
+	if ring.active == "" {
+		return nil, nil
+	}
+	return ring, nil
+}
+
+// encrypt returns the encrypted frame of b using the active key, the aad binds the value to its key.
+func (r *keyring) encrypt(b, aad []byte) ([]byte, error) {
+	aead := r.aeads[r.active]
+	frame := make([]byte, 0, frameHeaderLen+1+len(r.active)+aead.NonceSize()+len(b)+aead.Overhead())
+	frame = append(frame, frameMagic, frameEncrypted, byte(len(r.active)))
+	frame = append(frame, r.active...)
+	nonce := frame[len(frame) : len(frame)+aead.NonceSize()]
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	frame = frame[:len(frame)+len(nonce)]
+	return aead.Seal(frame, nonce, b, aad), nil
+}
+
+// decrypt returns the decrypted value of the encrypted frame body, the key id followed by the nonce
+// & the ciphertext.
+func (r *keyring) decrypt(b, aad []byte) ([]byte, error) {
+	if r == nil {
+		return nil, fmt.Errorf("%w: no encryption key configured", ErrDecrypt)
+	}
+	if len(b) == 0 || len(b) < 1+int(b[0]) {
+		return nil, fmt.Errorf("%w: %w", ErrDecrypt, errInvalidFrame)
+	}
+	id := string(b[1 : 1+b[0]])
+	b = b[1+b[0]:]
+	aead, ok := r.aeads[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown key id %s", ErrDecrypt, id)
+	}
+	if len(b) < aead.NonceSize() {
+		return nil, fmt.Errorf("%w: %w", ErrDecrypt, errInvalidFrame)
+	}
+	plain, err := aead.Open(nil, b[:aead.NonceSize()], b[aead.NonceSize():], aad)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDecrypt, err)
+	}
+	return plain, nil
+}
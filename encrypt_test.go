@@ -0,0 +1,101 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/qoinlyid/qore"
+	"github.com/stretchr/testify/assert"
+)
+
+func testEncryptionKey(b byte, size int) string {
+	return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{b}, size))
+}
+
+func newEncryptedTest(t *testing.T, keys string) *Instance {
+	t.Helper()
+	t.Setenv("CACHE_ENCRYPTION_KEYS", keys)
+	return newMemoryTest(t)
+}
+
+func TestParseKeyring(t *testing.T) {
+	ring, err := parseKeyring("")
+	assert.NoError(t, err, "Empty keys must be no error")
+	assert.Nil(t, ring, "Empty keys must be no keyring")
+
+	ring, err = parseKeyring("k2:" + testEncryptionKey(2, 32) + "\n k1:" + testEncryptionKey(1, 16))
+	assert.NoError(t, err, "Parse keys must be no error")
+	assert.Equal(t, "k2", ring.active, "First key must be the active key")
+	assert.Len(t, ring.aeads, 2, "All keys must be loaded")
+
+	for _, spec := range []string{
+		"k1",
+		":" + testEncryptionKey(1, 32),
+		"k1:not-base64",
+		"k1:" + testEncryptionKey(1, 10),
+		"k1:" + testEncryptionKey(1, 32) + ",k1:" + testEncryptionKey(2, 32),
+	} {
+		_, err = parseKeyring(spec)
+		assert.ErrorIs(t, err, ErrEncryptionKey, "Invalid keys must be error: "+spec)
+	}
+}
+
+func TestEncryptFrame(t *testing.T) {
+	ring, _ := parseKeyring("k1:" + testEncryptionKey(1, 32))
+	opts := frameOptions{keyring: ring, key: testKey}
+	b, err := encodeFrame([]byte(testValue), opts)
+	assert.NoError(t, err, "Encode frame must be no error")
+	assert.Equal(t, []byte{frameMagic, frameEncrypted}, b[:frameHeaderLen], "Value must be encrypted")
+	assert.NotContains(t, string(b), testValue, "Value must not be stored as plain text")
+
+	payload, _, err := decodeFrame(b, opts)
+	assert.NoError(t, err, "Decode frame must be no error")
+	assert.Equal(t, testValue, string(payload), "Decrypted value must be equal")
+
+	_, _, err = decodeFrame(b, frameOptions{keyring: ring, key: "other"})
+	assert.ErrorIs(t, err, ErrDecrypt, "Value moved to the other key must fail to decrypt")
+	_, _, err = decodeFrame(b, frameOptions{key: testKey})
+	assert.ErrorIs(t, err, ErrDecrypt, "Decrypt without keyring must be error")
+}
+
+func TestEncryptionRotation(t *testing.T) {
+	oldKey, newKey := "old:"+testEncryptionKey(1, 32), "new:"+testEncryptionKey(2, 32)
+	i := newEncryptedTest(t, oldKey)
+	_, err := i.Set(t.Context(), testKey).SetTTL(time.Minute).Put(testValue)
+	assert.NoError(t, err, "Put must be no error")
+
+	// Rotate: new writes use the new key, old values still decrypt with the retired key.
+	i.keyring, _ = parseKeyring(newKey + "," + oldKey)
+	var out string
+	assert.NoError(t, i.Get(t.Context(), testKey).Pull(&out), "Value of the retired key must be readable")
+	assert.Equal(t, testValue, out, "Value of the retired key must be equal")
+
+	_, err = i.Set(t.Context(), "rotated").SetTTL(time.Minute).Put(testValue)
+	assert.NoError(t, err, "Put must be no error")
+	b, _ := i.store.Get(t.Context(), i.cfg.Namespace+DefaultKeySeparator+"rotated")
+	assert.Equal(t, "new", string(b[frameHeaderLen+1:frameHeaderLen+1+b[frameHeaderLen]]), "New write must use the active key")
+
+	// Retired key removed.
+	i.keyring, _ = parseKeyring(newKey)
+	err = i.Get(t.Context(), testKey).SkipLocal().Pull(&out)
+	assert.ErrorIs(t, err, ErrDecrypt, "Value of the removed key must be decrypt error")
+	assert.NotErrorIs(t, err, ErrDecode, "Decrypt error must be distinct from decode error")
+}
+
+func TestEncryptionKeysFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys")
+	err := os.WriteFile(path, []byte("k1:"+testEncryptionKey(1, 32)+"\n"), 0o600)
+	assert.NoError(t, err, "Write keys file must be no error")
+	t.Setenv("CACHE_ENCRYPTION_KEYS_FILE", path)
+	i := newMemoryTest(t)
+	assert.Equal(t, "k1", i.keyring.active, "Key must be loaded from the file")
+
+	t.Setenv(qore.CONFIG_USED_KEY, "OS")
+	t.Setenv("CACHE_DRIVER", DriverMemory)
+	t.Setenv("CACHE_ENCRYPTION_KEYS_FILE", filepath.Join(t.TempDir(), "missing"))
+	assert.ErrorIs(t, New().Open(), ErrEncryptionKey, "Missing keys file must be error")
+}
@@ -0,0 +1,92 @@
+package cache
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+)
+
+// frameEnvelopeLen is the length of the envelope frame header: codec id, schema version & CRC-32 of the value.
+const frameEnvelopeLen = frameHeaderLen + 9
+
+// Envelope codec id, zero is the custom codec.
+const (
+	envelopeCodecDefault byte = iota + 1
+	envelopeCodecJSON
+	envelopeCodecMsgpack
+	envelopeCodecGob
+	envelopeCodecRaw
+	envelopeCodecProto
+)
+
+// errInvalidEnvelope is returned when the stored value does not match the expected envelope, the value
+// is treated as a cache miss.
+var errInvalidEnvelope = errors.New("invalid value envelope")
+
+// codecID returns the envelope codec id of the codec, the custom codecs share the id zero.
+func codecID(codec Codec) byte {
+	if codec == nil {
+		return envelopeCodecDefault
+	}
+	switch codec.Name() {
+	case CodecDefault:
+		return envelopeCodecDefault
+	case CodecJSON:
+		return envelopeCodecJSON
+	case CodecMsgpack:
+		return envelopeCodecMsgpack
+	case CodecGob:
+		return envelopeCodecGob
+	case CodecRaw:
+		return envelopeCodecRaw
+	case CodecProto:
+		return envelopeCodecProto
+	default:
+		return 0
+	}
+}
+
+// envelope returns the envelope frame of the encoded value.
+func envelope(payload []byte, codec byte, version uint32) []byte {
+	b := make([]byte, frameEnvelopeLen, frameEnvelopeLen+len(payload))
+	b[0], b[1], b[2] = frameMagic, frameEnvelope, codec
+	binary.BigEndian.PutUint32(b[3:], version)
+	binary.BigEndian.PutUint32(b[7:], crc32.ChecksumIEEE(payload))
+	return append(b, payload...)
+}
+
+// openEnvelope verifies the envelope frame against the opts & returns the encoded value.
+func openEnvelope(b []byte, opts frameOptions) ([]byte, error) {
+	if len(b) < frameEnvelopeLen {
+		return nil, errInvalidFrame
+	}
+	codec, version, sum := b[2], binary.BigEndian.Uint32(b[3:]), binary.BigEndian.Uint32(b[7:])
+	payload := b[frameEnvelopeLen:]
+	switch {
+	case crc32.ChecksumIEEE(payload) != sum:
+		return nil, fmt.Errorf("%w: checksum mismatch", errInvalidEnvelope)
+	case !opts.envelope:
+		return payload, nil
+	case codec != opts.codec:
+		return nil, fmt.Errorf("%w: codec id %d, expected %d", errInvalidEnvelope, codec, opts.codec)
+	case version != opts.version:
+		return nil, fmt.Errorf("%w: schema version %d, expected %d", errInvalidEnvelope, version, opts.version)
+	default:
+		return payload, nil
+	}
+}
+
+// dropInvalid deletes the value of the key that does not match the envelope when the config
+// EnvelopeDeleteInvalid is enabled, it is best effort.
+func (i *Instance) dropInvalid(ctx context.Context, key string) {
+	if !i.cfg.EnvelopeDeleteInvalid {
+		return
+	}
+	i.store.Del(ctx, key)
+	if i.local != nil {
+		i.local.del(key)
+	}
+	i.publishInvalidation(ctx, key)
+}
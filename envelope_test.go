@@ -0,0 +1,88 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnvelopeFrame(t *testing.T) {
+	opts := frameOptions{envelope: true, codec: envelopeCodecJSON, version: 2}
+	b, err := encodeFrame([]byte(testValue), opts)
+	assert.NoError(t, err, "Encode frame must be no error")
+	assert.Equal(t, []byte{frameMagic, frameEnvelope}, b[:frameHeaderLen], "Value must be enveloped")
+
+	payload, _, err := decodeFrame(b, opts)
+	assert.NoError(t, err, "Decode frame must be no error")
+	assert.Equal(t, testValue, string(payload), "Payload must be equal")
+	payload, _, err = decodeFrame(b, frameOptions{})
+	assert.NoError(t, err, "Enveloped value must be readable without the envelope option")
+	assert.Equal(t, testValue, string(payload), "Payload must be equal")
+
+	_, _, err = decodeFrame(b, frameOptions{envelope: true, codec: envelopeCodecJSON, version: 3})
+	assert.ErrorIs(t, err, errInvalidEnvelope, "Version mismatch must be error")
+	_, _, err = decodeFrame(b, frameOptions{envelope: true, codec: envelopeCodecMsgpack, version: 2})
+	assert.ErrorIs(t, err, errInvalidEnvelope, "Codec mismatch must be error")
+	_, _, err = decodeFrame([]byte(testValue), opts)
+	assert.ErrorIs(t, err, errInvalidEnvelope, "Value without envelope must be error")
+
+	corrupted := append([]byte{}, b...)
+	corrupted[len(corrupted)-1] ^= 0xFF
+	_, _, err = decodeFrame(corrupted, frameOptions{})
+	assert.ErrorIs(t, err, errInvalidEnvelope, "Checksum mismatch must be error")
+}
+
+func TestEnvelopeVersionMismatch(t *testing.T) {
+	i := newMemoryTest(t)
+	_, err := i.Set(t.Context(), testKey).SetVersion(1).SetTTL(time.Minute).Put(testValue)
+	assert.NoError(t, err, "Put must be no error")
+
+	var out string
+	assert.NoError(t, i.Get(t.Context(), testKey).SetVersion(1).Pull(&out), "Same version must be no error")
+	assert.Equal(t, testValue, out, "Value must be equal")
+
+	err = i.Get(t.Context(), testKey).SetVersion(2).Pull(&out)
+	assert.ErrorIs(t, err, ErrCacheMiss, "Version mismatch must be a miss")
+	assert.True(t, i.Has(t.Context(), testKey), "Invalid value must be kept by default")
+
+	calls := 0
+	err = i.Get(t.Context(), testKey).SetVersion(2).SetTTL(time.Minute).Remember(&out, func() (bool, any, error) {
+		calls++
+		return false, "fresh", nil
+	})
+	assert.NoError(t, err, "Remember must be no error")
+	assert.Equal(t, 1, calls, "Version mismatch must call the loader")
+	assert.Equal(t, "fresh", out, "Remember must return the loaded value")
+	assert.NoError(t, i.Get(t.Context(), testKey).SetVersion(2).Pull(&out), "Reloaded value must have the new version")
+}
+
+func TestEnvelopeDeleteInvalid(t *testing.T) {
+	t.Setenv("CACHE_SCHEMA_VERSION", "2")
+	t.Setenv("CACHE_ENVELOPE_DELETE_INVALID", "true")
+	i := newMemoryTest(t)
+	key := i.cfg.Namespace + DefaultKeySeparator + testKey
+	i.store.Set(t.Context(), key, []byte(testValue), time.Minute)
+
+	var out string
+	err := i.Get(t.Context(), testKey).Pull(&out)
+	assert.ErrorIs(t, err, ErrCacheMiss, "Value without envelope must be a miss")
+	assert.False(t, i.Has(t.Context(), testKey), "Invalid value must be deleted")
+
+	i.store.Set(t.Context(), key, []byte(testValue), time.Minute)
+	vals := map[string]string{}
+	res, err := i.GetMany(t.Context(), []string{testKey}).Pull(&vals)
+	assert.NoError(t, err, "GetMany must be no error")
+	assert.Equal(t, []string{testKey}, res.Misses, "Value without envelope must be reported as a miss")
+	assert.False(t, i.Has(t.Context(), testKey), "Invalid value must be deleted")
+}
+
+func TestTypedWithVersion(t *testing.T) {
+	i := newMemoryTest(t)
+	v1 := For[string](i, "typed").WithVersion(1)
+	assert.NoError(t, v1.Set(t.Context(), testKey, testValue, time.Minute), "Set must be no error")
+
+	_, found, err := v1.WithVersion(2).Get(t.Context(), testKey)
+	assert.NoError(t, err, "Version mismatch must be no error")
+	assert.False(t, found, "Version mismatch must be not found")
+}
@@ -1,11 +1,124 @@
 package cache
 
-import "errors"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/redis/go-redis/v9"
+)
 
 var (
-	ErrClientNil        = errors.New("redis client is null")
-	ErrClientNotCluster = errors.New("redis set to cluster mode, but unfortunately the client is not cluster client")
-	ErrEmptyKey         = errors.New("cache key cannot be empty")
-	ErrEmptyPrefix      = errors.New("prefix cannot be empty")
-	ErrOutNonPointer    = errors.New("out type non-pointer")
+	ErrClientNil            = errors.New("redis client is null")
+	ErrStoreNil             = errors.New("cache store is null")
+	ErrUnknownDriver        = errors.New("unknown cache driver")
+	ErrClientNotCluster     = errors.New("redis set to cluster mode, but unfortunately the client is not cluster client")
+	ErrEmptyKey             = errors.New("cache key cannot be empty")
+	ErrEmptyPrefix          = errors.New("prefix cannot be empty")
+	ErrOutNonPointer        = errors.New("out type non-pointer")
+	ErrTrackingNotSupported = errors.New("client-side caching tracking is not supported by the store")
+	ErrNotFound             = errors.New("cache value not found")
+	ErrUnknownCodec         = errors.New("unknown cache codec")
+	ErrUnknownCompression   = errors.New("unknown cache compression")
+	ErrEncryptionKey        = errors.New("invalid cache encryption key")
+
+	ErrRateLimitNotSupported = errors.New("rate limiter is not supported by the store")
+	ErrRateLimitInvalid      = errors.New("rate limit and its period must be positive")
+
+	ErrSemaphoreNotSupported = errors.New("semaphore is not supported by the store")
+	ErrSemaphoreInvalid      = errors.New("semaphore limit must be positive and lease at least 1ms")
+	ErrSemaphoreFull         = errors.New("semaphore has no permit left")
+	ErrPermitLost            = errors.New("semaphore permit is expired or released")
 )
+
+// Operation error kind.
+var (
+	ErrCacheMiss          = errors.New("cache miss")
+	ErrBackendUnavailable = errors.New("cache backend unavailable")
+	ErrDecode             = errors.New("cache value decode failed")
+	ErrTimeout            = errors.New("cache operation timeout")
+	ErrDecrypt            = errors.New("cache value decrypt failed")
+)
+
+// OpError describes the failed cache operation. Use errors.Is to match its kind (ErrCacheMiss,
+// ErrBackendUnavailable, ErrDecode, ErrTimeout or ErrDecrypt) or the underlying error. For backward compatibility
+// ErrCacheMiss also matches redis.Nil.
+//
+//	if errors.Is(err, cache.ErrCacheMiss) {
+//		// absent
+//	}
+type OpError struct {
+	// Op is the operation, e.g. "get", "set", "del", "has" or "keys".
+	Op string
+
+	// Key is the key of the operation (without the namespace), if any.
+	Key string
+
+	// Kind is the error kind, nil if the error is not classified.
+	Kind error
+
+	// Err is the underlying error.
+	Err error
+}
+
+// Error returns the error message.
+func (e *OpError) Error() string {
+	if e.Key == "" {
+		return fmt.Sprintf("cache %s: %v", e.Op, e.Err)
+	}
+	return fmt.Sprintf("cache %s %s: %v", e.Op, e.Key, e.Err)
+}
+
+// Unwrap returns the error kind & the underlying error.
+func (e *OpError) Unwrap() []error {
+	errs := []error{e.Err}
+	if e.Kind != nil {
+		errs = append(errs, e.Kind)
+	}
+	if e.Kind == ErrCacheMiss {
+		errs = append(errs, redis.Nil)
+	}
+	return errs
+}
+
+// opError wraps the store error of the operation with its kind, nil is returned for nil error.
+func opError(op, key string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var opErr *OpError
+	if errors.As(err, &opErr) {
+		return err
+	}
+	return &OpError{Op: op, Key: key, Kind: errorKind(err), Err: err}
+}
+
+// decodeError wraps the decode error of the operation, the decryption failure is kept as its own kind.
+func decodeError(op, key string, out any, err error) error {
+	if errors.Is(err, ErrDecrypt) {
+		return &OpError{Op: op, Key: key, Kind: ErrDecrypt, Err: err}
+	}
+	return &OpError{Op: op, Key: key, Kind: ErrDecode, Err: fmt.Errorf("failed to decode value to %T: %w", out, err)}
+}
+
+// errorKind classifies the store error.
+func errorKind(err error) error {
+	var netErr net.Error
+	switch {
+	case isMiss(err):
+		return ErrCacheMiss
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return ErrTimeout
+	case isConnError(err):
+		return ErrBackendUnavailable
+	default:
+		return nil
+	}
+}
+
+// isMiss reports whether the store error means the key does not exist, redis.Nil is still accepted
+// from the Store implementations.
+func isMiss(err error) bool {
+	return errors.Is(err, ErrCacheMiss) || errors.Is(err, redis.Nil)
+}
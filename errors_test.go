@@ -0,0 +1,86 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorKind(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected error
+	}{
+		{ErrCacheMiss, ErrCacheMiss},
+		{redis.Nil, ErrCacheMiss},
+		{context.DeadlineExceeded, ErrTimeout},
+		{io.EOF, ErrBackendUnavailable},
+		{redis.ErrClosed, ErrBackendUnavailable},
+		{errors.New("WRONGTYPE"), nil},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, errorKind(test.err), fmt.Sprintf("Kind of %s should be %v", test.err, test.expected))
+	}
+}
+
+func TestOpError(t *testing.T) {
+	err := opError("get", "user:1", ErrCacheMiss)
+	assert.ErrorIs(t, err, ErrCacheMiss, "Miss must match ErrCacheMiss")
+	assert.ErrorIs(t, err, redis.Nil, "Miss must match redis.Nil for backward compatibility")
+	assert.Equal(t, "cache get user:1: cache miss", err.Error(), "Message must contain the op & the key")
+
+	var opErr *OpError
+	assert.ErrorAs(t, err, &opErr, "Error must be OpError")
+	assert.Equal(t, "get", opErr.Op, "Op should be get")
+	assert.Same(t, err, opError("set", "user:1", err), "OpError must not be wrapped twice")
+	assert.NoError(t, opError("get", "user:1", nil), "Nil error must stay nil")
+
+	err = opError("del", "", io.EOF)
+	assert.ErrorIs(t, err, ErrBackendUnavailable, "EOF must match ErrBackendUnavailable")
+	assert.ErrorIs(t, err, io.EOF, "Underlying error must be matched")
+	assert.NotErrorIs(t, err, redis.Nil, "Non-miss must not match redis.Nil")
+}
+
+func TestGetErrors(t *testing.T) {
+	i := newMemoryTest(t)
+	var val int
+	err := i.Get(t.Context(), testKey).Pull(&val)
+	assert.ErrorIs(t, err, ErrCacheMiss, "Pull missing key must be ErrCacheMiss")
+
+	i.Set(t.Context(), testKey).Put("not a number")
+	err = i.Get(t.Context(), testKey).Pull(&val)
+	assert.ErrorIs(t, err, ErrDecode, "Pull of mismatched type must be ErrDecode")
+	assert.NotErrorIs(t, err, ErrCacheMiss, "Decode failure must not be a miss")
+}
+
+func TestHasE(t *testing.T) {
+	i := newMemoryTest(t)
+	exists, err := i.HasE(t.Context(), testKey)
+	assert.NoError(t, err, "HasE must be no error")
+	assert.False(t, exists, "Key must not exist")
+
+	i.Set(t.Context(), testKey).Put(testValue)
+	exists, err = i.HasE(t.Context(), testKey)
+	assert.NoError(t, err, "HasE must be no error")
+	assert.True(t, exists, "Key must exist")
+
+	i.store = downStore{i.store}
+	exists, err = i.HasE(t.Context(), testKey)
+	assert.ErrorIs(t, err, ErrBackendUnavailable, "HasE must tell the unavailable backend")
+	assert.False(t, exists, "Key must not exist when the backend is unavailable")
+	assert.False(t, i.Has(t.Context(), testKey), "Has must be false when the backend is unavailable")
+}
+
+// downStore is a Store with the unavailable backend.
+type downStore struct {
+	Store
+}
+
+func (downStore) Exists(context.Context, ...string) (int64, error) {
+	return 0, io.EOF
+}
@@ -0,0 +1,200 @@
+package cache
+
+import (
+	"cmp"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// frameMagic prefixes the stored value that carries a frame header, the header is the magic byte followed
+// by the frame kind. 0xC1 is never used by msgpack, so mostly only the raw string & bytes values collide
+// with it, the encoded values that start with it are escaped.
+const frameMagic byte = 0xC1
+
+// Frame kind.
+const (
+	frameEscape     byte = 'R' // Raw value that starts with the magic byte.
+	frameMeta       byte = 'M' // Value with Remember metadata.
+	frameNotFound   byte = 'N' // Tombstone of the negative cached key.
+	frameCompressed byte = 'Z' // Compressed value, followed by the algorithm id.
+	frameEncrypted  byte = 'E' // Encrypted value, followed by the key id, the nonce & the ciphertext.
+	frameEnvelope   byte = 'V' // Versioned value, followed by the codec id, the schema version & the checksum.
+)
+
+// frameHeaderLen is the length of the magic byte & the frame kind.
+const frameHeaderLen = 2
+
+// frameMaxDepth is the max number of the nested frames.
+const frameMaxDepth = 8
+
+// frameMetaLen is the length of the meta frame header: soft expiry & recompute time.
+const frameMetaLen = frameHeaderLen + 16
+
+// errInvalidFrame is returned when the stored value starts with the magic byte but its frame is malformed.
+var errInvalidFrame = errors.New("invalid value frame")
+
+// valueMeta is the Remember metadata carried by the value.
+type valueMeta struct {
+	softExpire int64         // Unix nano of the soft expiration, zero means never.
+	delta      time.Duration // How long the loader took to compute the value.
+}
+
+// stale reports whether the value should be refreshed, either the soft TTL is elapsed or the
+// XFetch probabilistic early expiration fires. The higher beta the earlier, beta <= 0 disables it.
+func (m *valueMeta) stale(now time.Time, beta float64) bool {
+	if m == nil || m.softExpire == 0 {
+		return false
+	}
+	expire := time.Unix(0, m.softExpire)
+	if !now.Before(expire) {
+		return true
+	}
+	if beta <= 0 || m.delta <= 0 {
+		return false
+	}
+	gap := -float64(m.delta) * beta * math.Log(1-rand.Float64())
+	return !now.Add(time.Duration(gap)).Before(expire)
+}
+
+// frameOptions defines the frames wrapping the encoded value.
+type frameOptions struct {
+	meta        *valueMeta
+	compression string   // Compression algorithm, empty or CompressionNone means uncompressed.
+	threshold   int      // Min size of the encoded value to be compressed.
+	keyring     *keyring // Encryption keys, nil means unencrypted.
+	key         string   // Stored key, authenticated with the encrypted value.
+	envelope    bool     // Whether the value is wrapped in the versioned envelope.
+	codec       byte     // Codec id of the envelope.
+	version     uint32   // Schema version of the envelope.
+}
+
+// frameOptions returns the frame options of the stored key. The per call compression takes precedence
+// over the config Compression & the per call version over the config SchemaVersion, the envelope is used
+// when the config Envelope is enabled or a version is set.
+func (i *Instance) frameOptions(key string, codec Codec, version uint32, meta *valueMeta, compression string) frameOptions {
+	if compression == "" {
+		compression = i.cfg.Compression
+	}
+	version = cmp.Or(version, i.cfg.SchemaVersion)
+	return frameOptions{
+		meta:        meta,
+		compression: compression,
+		threshold:   i.cfg.CompressionThreshold,
+		keyring:     i.keyring,
+		key:         key,
+		envelope:    i.cfg.Envelope || version > 0,
+		codec:       codecID(codec),
+		version:     version,
+	}
+}
+
+// encodeFrame returns the stored form of the encoded value. The frames are nested from the outermost:
+// meta, encryption, compression then envelope or escape.
+func encodeFrame(payload []byte, opts frameOptions) ([]byte, error) {
+	b := payload
+	switch {
+	case opts.envelope:
+		b = envelope(payload, opts.codec, opts.version)
+	case len(b) > 0 && b[0] == frameMagic:
+		b = append([]byte{frameMagic, frameEscape}, b...)
+	}
+	if opts.compression != "" && opts.compression != CompressionNone && len(payload) >= opts.threshold {
+		z, err := compress(opts.compression, b)
+		if err != nil {
+			return nil, err
+		}
+		// Keep it uncompressed when it does not pay off.
+		if len(z) < len(b) {
+			b = z
+		}
+	}
+	if opts.keyring != nil {
+		e, err := opts.keyring.encrypt(b, []byte(opts.key))
+		if err != nil {
+			return nil, err
+		}
+		b = e
+	}
+	if opts.meta != nil {
+		framed := make([]byte, frameMetaLen, frameMetaLen+len(b))
+		framed[0], framed[1] = frameMagic, frameMeta
+		binary.BigEndian.PutUint64(framed[2:], uint64(opts.meta.softExpire))
+		binary.BigEndian.PutUint64(framed[10:], uint64(opts.meta.delta))
+		b = append(framed, b...)
+	}
+	return b, nil
+}
+
+// tombstone returns the stored form of the negative cached key.
+func tombstone() []byte {
+	return []byte{frameMagic, frameNotFound}
+}
+
+// decodeFrame unwraps the frames of the stored value & returns the encoded value & its metadata (if any),
+// ErrNotFound is returned for the tombstone. The value without frame is returned as is, the encrypted value
+// is decrypted with the opts keyring. When the opts envelope is set, the value without envelope or
+// with the other codec, the other version or a bad checksum fails with errInvalidEnvelope.
+func decodeFrame(b []byte, opts frameOptions) ([]byte, *valueMeta, error) {
+	var meta *valueMeta
+	for depth := 0; len(b) > 0 && b[0] == frameMagic; depth++ {
+		if len(b) < frameHeaderLen || depth >= frameMaxDepth {
+			return nil, nil, errInvalidFrame
+		}
+		switch b[1] {
+		case frameEscape:
+			b = b[frameHeaderLen:]
+			if opts.envelope {
+				return nil, nil, fmt.Errorf("%w: value has no envelope", errInvalidEnvelope)
+			}
+			return b, meta, nil
+		case frameEnvelope:
+			payload, err := openEnvelope(b, opts)
+			if err != nil {
+				return nil, nil, err
+			}
+			return payload, meta, nil
+		case frameMeta:
+			if len(b) < frameMetaLen {
+				return nil, nil, errInvalidFrame
+			}
+			meta = &valueMeta{
+				softExpire: int64(binary.BigEndian.Uint64(b[2:])),
+				delta:      time.Duration(binary.BigEndian.Uint64(b[10:])),
+			}
+			b = b[frameMetaLen:]
+		case frameCompressed:
+			d, err := decompress(b[frameHeaderLen:], decompressMaxSize)
+			if err != nil {
+				return nil, nil, err
+			}
+			b = d
+		case frameEncrypted:
+			d, err := opts.keyring.decrypt(b[frameHeaderLen:], []byte(opts.key))
+			if err != nil {
+				return nil, nil, err
+			}
+			b = d
+		case frameNotFound:
+			return nil, nil, ErrNotFound
+		default:
+			return nil, nil, errInvalidFrame
+		}
+	}
+	if opts.envelope {
+		return nil, nil, fmt.Errorf("%w: value has no envelope", errInvalidEnvelope)
+	}
+	return b, meta, nil
+}
+
+// decodeValue parses the stored value to the out using the codec.
+func decodeValue(codec Codec, b []byte, out any, opts frameOptions) error {
+	payload, _, err := decodeFrame(b, opts)
+	if err != nil {
+		return err
+	}
+	return codec.Unmarshal(payload, out)
+}
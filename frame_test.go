@@ -0,0 +1,65 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFrameEscape(t *testing.T) {
+	raw := []byte{frameMagic, frameMeta, 'x'}
+	b, err := encodeFrame(raw, frameOptions{})
+	assert.NoError(t, err, "Encode frame must be no error")
+	assert.Equal(t, []byte{frameMagic, frameEscape}, b[:frameHeaderLen], "Raw value starts with magic must be escaped")
+	payload, meta, err := decodeFrame(b, frameOptions{})
+	assert.NoError(t, err, "Decode frame must be no error")
+	assert.Nil(t, meta, "Escaped value must have no meta")
+	assert.Equal(t, raw, payload, "Payload must be the raw value")
+
+	plain := []byte(testValue)
+	b, _ = encodeFrame(plain, frameOptions{})
+	assert.Equal(t, plain, b, "Plain value must be stored as is")
+}
+
+func TestFrameMeta(t *testing.T) {
+	expected := &valueMeta{softExpire: time.Now().UnixNano(), delta: 42 * time.Millisecond}
+	b, _ := encodeFrame([]byte(testValue), frameOptions{meta: expected})
+	payload, meta, err := decodeFrame(b, frameOptions{})
+	assert.NoError(t, err, "Decode frame must be no error")
+	assert.Equal(t, expected, meta, "Meta must be equal to the encoded meta")
+	assert.Equal(t, testValue, string(payload), "Payload must be the encoded value")
+
+	_, _, err = decodeFrame([]byte{frameMagic, frameMeta, 1}, frameOptions{})
+	assert.ErrorIs(t, err, errInvalidFrame, "Truncated meta frame must be error")
+	_, _, err = decodeFrame([]byte{frameMagic, '?'}, frameOptions{})
+	assert.ErrorIs(t, err, errInvalidFrame, "Unknown frame kind must be error")
+}
+
+func TestValueMetaStale(t *testing.T) {
+	now := time.Now()
+	assert.False(t, (*valueMeta)(nil).stale(now, 1), "Nil meta must never be stale")
+	assert.False(t, (&valueMeta{}).stale(now, 1), "Meta without soft expiration must never be stale")
+
+	meta := &valueMeta{softExpire: now.Add(-time.Second).UnixNano()}
+	assert.True(t, meta.stale(now, 0), "Elapsed soft expiration must be stale")
+
+	meta = &valueMeta{softExpire: now.Add(time.Hour).UnixNano(), delta: time.Millisecond}
+	assert.False(t, meta.stale(now, 0), "Early refresh must be disabled for beta 0")
+	assert.False(t, meta.stale(now, 1), "Far expiration must not be refreshed early")
+
+	meta = &valueMeta{softExpire: now.Add(time.Millisecond).UnixNano(), delta: time.Hour}
+	assert.True(t, meta.stale(now, 1), "Near expiration of expensive value must be refreshed early")
+}
+
+func TestRawValueWithMagicByte(t *testing.T) {
+	i := newMemoryTest(t)
+	raw := []byte{frameMagic, frameMeta, 'x'}
+	_, err := i.Set(t.Context(), testKey).Put(raw)
+	assert.NoError(t, err, "Put must be no error")
+
+	var out []byte
+	err = i.Get(t.Context(), testKey).Pull(&out)
+	assert.NoError(t, err, "Pull must be no error")
+	assert.Equal(t, raw, out, "Raw value must be read back as is")
+}
@@ -9,12 +9,26 @@ import (
 
 type RememberFn func() (forever bool, val any, err error)
 
+// RememberContextFn is the RememberFn receiving the context of the loader, it carries the loader span
+// when tracing is enabled.
+type RememberContextFn func(ctx context.Context) (forever bool, val any, err error)
+
 // getter is a method-chaining configuration struct for cache retrieve operations.
 type getter struct {
 	base
+	ttl         time.Duration
+	skipLocal   bool
+	lock        bool
+	lockTTL     time.Duration
+	lockWait    time.Duration
+	softTTL     time.Duration
+	beta        float64
+	negativeTTL time.Duration
+	codec       Codec
+	version     uint32
 
 	// getFn is a closure function that called to retrieve cache from the backend.
-	getFn func(get *getter, out any, rem ...RememberFn) error
+	getFn func(get *getter, out any, rem ...RememberContextFn) error
 }
 
 // Get initializes a new getter instance for the given key & prefix (if any).
@@ -32,9 +46,11 @@ func (i *Instance) Get(ctx context.Context, key string, prefix ...string) *gette
 	if ctx == nil {
 		ctx, cancel = context.WithTimeout(context.Background(), time.Second)
 	}
+	var keyPrefix string
 	if len(prefix) > 0 {
 		if !qore.ValidationIsEmpty(prefix[0]) {
 			key = prefix[0] + DefaultKeySeparator + key
+			keyPrefix = prefix[0]
 		}
 	}
 
@@ -44,11 +60,65 @@ func (i *Instance) Get(ctx context.Context, key string, prefix ...string) *gette
 			ctx:    ctx,
 			cancel: cancel,
 			key:    key,
+			prefix: keyPrefix,
 		},
 		getFn: i.get,
 	}
 }
 
+// SetTTL sets the time-to-live (TTL) duration for the cache entry stored by Remember.
+// If TTL is <= 0, the default value will be used is 1 minutes.
+//
+//	get.SetTTL(10 * time.Minute)
+func (g *getter) SetTTL(ttl time.Duration) *getter { g.ttl = ttl; return g }
+
+// SkipLocal bypasses the in-process L1 tier for this call, the value is read from the backend store
+// and the L1 tier is refreshed with it.
+//
+//	get.SkipLocal().Pull(&out)
+func (g *getter) SkipLocal() *getter { g.skipLocal = true; return g }
+
+// Lock enables the distributed lock of Remember for this call regardless of the config RememberLock,
+// ttl & wait <= 0 use the config RememberLockTTL & RememberLockWait.
+//
+//	get.Lock(30*time.Second, 10*time.Second).Remember(&out, loader)
+func (g *getter) Lock(ttl, wait time.Duration) *getter {
+	g.lock, g.lockTTL, g.lockWait = true, ttl, wait
+	return g
+}
+
+// SetSoftTTL sets the soft time-to-live of the cache entry stored by Remember, it should be shorter than TTL.
+// Once the soft TTL is elapsed, Remember returns the stale value immediately & refreshes it in the background
+// while TTL is still enforced by the backend store.
+//
+//	get.SetTTL(time.Hour).SetSoftTTL(5 * time.Minute)
+func (g *getter) SetSoftTTL(ttl time.Duration) *getter { g.softTTL = ttl; return g }
+
+// EarlyRefresh enables the XFetch probabilistic early expiration of Remember, the entry is refreshed
+// in the background before it expires with probability based on how long the loader took.
+// Beta 1 is the recommended value, higher beta refreshes earlier, <= 0 disables it.
+//
+//	get.EarlyRefresh(1).Remember(&out, loader)
+func (g *getter) EarlyRefresh(beta float64) *getter { g.beta = beta; return g }
+
+// SetNegativeTTL sets the time-to-live of the tombstone stored by Remember when the `RememberFn` returns
+// ErrNotFound, <= 0 uses the config NegativeTTL.
+//
+//	get.SetNegativeTTL(10 * time.Second)
+func (g *getter) SetNegativeTTL(ttl time.Duration) *getter { g.negativeTTL = ttl; return g }
+
+// SetCodec overrides the instance codec for this call, it must match the codec used to store the value.
+// Remember stores the loaded value using the same codec.
+//
+//	get.SetCodec(cache.JSONCodec)
+func (g *getter) SetCodec(codec Codec) *getter { g.codec = codec; return g }
+
+// SetVersion overrides the config SchemaVersion for this call, the value stored with the other version
+// is read as a miss. Remember stores the loaded value with the same version.
+//
+//	get.SetVersion(2)
+func (g *getter) SetVersion(version uint32) *getter { g.version = version; return g }
+
 // Pull retrieves item(s) from cache and parse it to the given output.
 //
 //	var out any
@@ -64,6 +134,11 @@ func (g *getter) Pull(out any) error {
 // but also store a default value if the requested item(s) does not exist.
 //
 // In the `RememberFn` first return value is bool to determines is the cache entry should be persist or not.
+// Concurrent Remember of the same missing key in the same process share a single `RememberFn` call & its value,
+// see Lock to coalesce the calls across instances.
+//
+// Return ErrNotFound from the `RememberFn` to cache the absence of the value, the following Pull & Remember
+// of the key return ErrNotFound without calling the `RememberFn` until the negative TTL is elapsed.
 //
 //	var out string
 //	err := get.Remember(&out, func() (forever bool, val any, err error) {
@@ -73,5 +148,25 @@ func (g *getter) Pull(out any) error {
 //		log.Println(err)
 //	}
 func (g *getter) Remember(out any, rem RememberFn) error {
+	if rem == nil {
+		return g.getFn(g, out, nil)
+	}
+	return g.getFn(g, out, func(context.Context) (bool, any, error) {
+		return rem()
+	})
+}
+
+// RememberContext works like Remember, the loader receives the context of the Remember call
+// carrying the loader span, so the spans of its calls are nested under the cache span.
+//
+//	var out User
+//	err := get.RememberContext(&out, func(ctx context.Context) (forever bool, val any, err error) {
+//		user, err := db.GetUser(ctx, 123)
+//		return false, user, err
+//	})
+//	if err != nil {
+//		log.Println(err)
+//	}
+func (g *getter) RememberContext(out any, rem RememberContextFn) error {
 	return g.getFn(g, out, rem)
 }
@@ -0,0 +1,206 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"time"
+
+	"github.com/qoinlyid/qore"
+)
+
+// ManyResult defines the per-key outcome of the multiple keys retrieve operation.
+type ManyResult struct {
+	// Misses is the keys that do not exist.
+	Misses []string
+
+	// Errors is the per-key decode error, or ErrNotFound for the negative cached keys.
+	Errors map[string]error
+}
+
+// Err joins all per-key errors, nil if there is no error.
+func (r ManyResult) Err() error {
+	if len(r.Errors) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(r.Errors))
+	for key := range r.Errors {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	errs := make([]error, len(keys))
+	for n, key := range keys {
+		errs[n] = fmt.Errorf("key %s: %w", key, r.Errors[key])
+	}
+	return errors.Join(errs...)
+}
+
+// multiGetter is a method-chaining configuration struct for multiple keys retrieve operations.
+type multiGetter struct {
+	ctx       context.Context
+	cancel    context.CancelFunc
+	keys      []string
+	prefix    string
+	skipLocal bool
+	codec     Codec
+	version   uint32
+
+	// getFn is a closure function that called to retrieve caches from the backend.
+	getFn func(get *multiGetter, out any) (ManyResult, error)
+}
+
+// GetMany initializes a new multiple keys getter instance for the given keys & prefix (if any).
+// If the provided context is nil, a new background context with
+// a default timeout will be created. The cancel function is stored
+// in the getter and will be called automatically when the final
+// method (e.g., Pull) completes.
+//
+// This is the entry point for method chaining.
+//
+//	get := cache.GetMany(ctx, []string{"1", "2", "3"}, "user")
+func (i *Instance) GetMany(ctx context.Context, keys []string, prefix ...string) *multiGetter {
+	// Value modifier.
+	var cancel context.CancelFunc
+	if ctx == nil {
+		ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	}
+	get := &multiGetter{
+		ctx:    ctx,
+		cancel: cancel,
+		keys:   keys,
+		getFn:  i.getMany,
+	}
+	if len(prefix) > 0 {
+		get.prefix = prefix[0]
+	}
+
+	// Return getter.
+	return get
+}
+
+// SkipLocal bypasses the in-process L1 tier for this call.
+//
+//	get.SkipLocal().Pull(&out)
+func (g *multiGetter) SkipLocal() *multiGetter { g.skipLocal = true; return g }
+
+// SetCodec overrides the instance codec for this call, it must match the codec used to store the values.
+//
+//	get.SetCodec(cache.JSONCodec)
+func (g *multiGetter) SetCodec(codec Codec) *multiGetter { g.codec = codec; return g }
+
+// SetVersion overrides the config SchemaVersion for this call, the values stored with the other version
+// are reported as misses.
+//
+//	get.SetVersion(2)
+func (g *multiGetter) SetVersion(version uint32) *multiGetter { g.version = version; return g }
+
+// Pull retrieves the items from cache in a single round trip (per hash slot in cluster mode)
+// and parses them to the given output, either a pointer to map[string]T keyed by the given keys,
+// or a pointer to []T in the same order as the given keys. The missing keys are reported in the
+// ManyResult Misses and left out of the map (or zero in the slice), the per-key decode errors are
+// reported in the ManyResult Errors. The returned error is for the whole operation.
+//
+//	var users map[string]User
+//	res, err := get.Pull(&users)
+//	if err != nil {
+//		log.Println(err)
+//	}
+//	log.Println(res.Misses, res.Err())
+func (g *multiGetter) Pull(out any) (ManyResult, error) {
+	return g.getFn(g, out)
+}
+
+// getMany helper to retrieve multiple values from the backend store.
+func (i *Instance) getMany(get *multiGetter, out any) (res ManyResult, err error) {
+	defer func() {
+		if get.cancel != nil {
+			get.cancel()
+		}
+		// Zero out all fields to help GC or prepare for reuse
+		*get = multiGetter{}
+	}()
+
+	// Validate.
+	if err := i.validateStore(); err != nil {
+		return res, err
+	}
+	outVal := reflect.ValueOf(out)
+	if outVal.Kind() != reflect.Ptr || outVal.IsNil() {
+		return res, fmt.Errorf("%w %T", ErrOutNonPointer, out)
+	}
+	container := outVal.Elem()
+	switch {
+	case container.Kind() == reflect.Map && container.Type().Key().Kind() == reflect.String:
+		if container.IsNil() {
+			container.Set(reflect.MakeMapWithSize(container.Type(), len(get.keys)))
+		}
+	case container.Kind() == reflect.Slice:
+		container.Set(reflect.MakeSlice(container.Type(), len(get.keys), len(get.keys)))
+	default:
+		return res, fmt.Errorf("out must be a pointer to map[string]T or []T, got %T", out)
+	}
+	op := &operation{name: OpGet, keys: make([]string, len(get.keys)), prefix: get.prefix}
+	fullKeys := make([]string, len(get.keys))
+	for idx, key := range get.keys {
+		if qore.ValidationIsEmpty(key) {
+			return res, ErrEmptyKey
+		}
+		if !qore.ValidationIsEmpty(get.prefix) {
+			key = get.prefix + DefaultKeySeparator + key
+		}
+		op.keys[idx] = key
+		fullKeys[idx] = i.cfg.Namespace + DefaultKeySeparator + key
+	}
+	if len(fullKeys) == 0 {
+		return res, nil
+	}
+
+	// Exec.
+	err = i.instrument(get.ctx, op, func(ctx context.Context) error {
+		vals, err := i.fetchMany(ctx, fullKeys, get.skipLocal)
+		if err != nil {
+			return opError("get", "", err)
+		}
+
+		// Decode.
+		codec := i.valueCodec(get.codec)
+		elemType := container.Type().Elem()
+		for idx, b := range vals {
+			key := get.keys[idx]
+			if b == nil {
+				res.Misses = append(res.Misses, key)
+				continue
+			}
+			op.size += len(b)
+			elem := reflect.New(elemType)
+			err := decodeValue(codec, b, elem.Interface(), i.frameOptions(fullKeys[idx], codec, get.version, nil, ""))
+			if errors.Is(err, errInvalidEnvelope) {
+				i.dropInvalid(ctx, fullKeys[idx])
+				res.Misses = append(res.Misses, key)
+				continue
+			}
+			if err != nil {
+				if res.Errors == nil {
+					res.Errors = make(map[string]error)
+				}
+				if errors.Is(err, ErrNotFound) {
+					res.Errors[key] = err
+					continue
+				}
+				res.Errors[key] = decodeError("get", "", elem.Interface(), err)
+				i.logDecode(ctx, op.keys[idx], get.prefix, res.Errors[key])
+				continue
+			}
+			if container.Kind() == reflect.Map {
+				container.SetMapIndex(reflect.ValueOf(key).Convert(container.Type().Key()), elem.Elem())
+			} else {
+				container.Index(idx).Set(elem.Elem())
+			}
+		}
+		op.hits, op.misses = len(vals)-len(res.Misses), len(res.Misses)
+		return nil
+	})
+	return res, err
+}
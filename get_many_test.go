@@ -0,0 +1,115 @@
+package cache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetManyPullMap(t *testing.T) {
+	i := newMemoryTest(t)
+	for n := range 3 {
+		i.Set(t.Context(), fmt.Sprint(n)).SetPrefix(testPrefix).Put(n * 10)
+	}
+
+	var vals map[string]int
+	res, err := i.GetMany(t.Context(), []string{"0", "1", "2", "3"}, testPrefix).Pull(&vals)
+	assert.NoError(t, err, "Pull must be no error")
+	assert.Equal(t, map[string]int{"0": 0, "1": 10, "2": 20}, vals, "Values must be keyed by the given keys")
+	assert.Equal(t, []string{"3"}, res.Misses, "Missing key must be reported")
+	assert.NoError(t, res.Err(), "Must be no per-key error")
+}
+
+func TestGetManyPullNamedKeyMap(t *testing.T) {
+	type userID string
+	i := newMemoryTest(t)
+	i.Set(t.Context(), "1").SetPrefix(testPrefix).Put(10)
+
+	var vals map[userID]int
+	_, err := i.GetMany(t.Context(), []string{"1"}, testPrefix).Pull(&vals)
+	assert.NoError(t, err, "Pull must be no error")
+	assert.Equal(t, map[userID]int{"1": 10}, vals, "Values must be keyed by the named key type")
+}
+
+func TestGetManyPullSlice(t *testing.T) {
+	i := newMemoryTest(t)
+	i.Set(t.Context(), "a").Put("A")
+	i.Set(t.Context(), "c").Put("C")
+
+	var vals []string
+	res, err := i.GetMany(t.Context(), []string{"a", "b", "c"}).Pull(&vals)
+	assert.NoError(t, err, "Pull must be no error")
+	assert.Equal(t, []string{"A", "", "C"}, vals, "Values must be in the same order as the keys")
+	assert.Equal(t, []string{"b"}, res.Misses, "Missing key must be reported")
+}
+
+func TestGetManyDecodeError(t *testing.T) {
+	i := newMemoryTest(t)
+	i.Set(t.Context(), "num").Put(1)
+	i.Set(t.Context(), "text").Put("not a number")
+
+	var vals map[string]int
+	res, err := i.GetMany(t.Context(), []string{"num", "text"}).Pull(&vals)
+	assert.NoError(t, err, "Decode error must not fail the whole operation")
+	assert.Equal(t, map[string]int{"num": 1}, vals, "Decoded value must be kept")
+	assert.Contains(t, res.Errors, "text", "Decode error must be reported per key")
+	assert.Error(t, res.Err(), "Err must join per-key errors")
+}
+
+func TestGetManyInvalidOut(t *testing.T) {
+	i := newMemoryTest(t)
+	var vals map[string]int
+	_, err := i.GetMany(t.Context(), []string{"a"}).Pull(vals)
+	assert.ErrorIs(t, err, ErrOutNonPointer, "Non-pointer out must be error")
+
+	var val int
+	_, err = i.GetMany(t.Context(), []string{"a"}).Pull(&val)
+	assert.Error(t, err, "Non map or slice out must be error")
+
+	_, err = i.GetMany(t.Context(), []string{""}).Pull(&vals)
+	assert.ErrorIs(t, err, ErrEmptyKey, "Empty key must be error")
+}
+
+func TestGetManyLocal(t *testing.T) {
+	i := newLocalTest(t)
+	i.Set(t.Context(), "a").Put("A")
+	i.local.flush()
+
+	var vals []string
+	i.GetMany(t.Context(), []string{"a", "b"}).Pull(&vals)
+	i.GetMany(t.Context(), []string{"a", "b"}).Pull(&vals)
+	stats := i.Stats()
+	assert.Equal(t, uint64(1), stats.Local.Hits, "Second read must hit the L1 tier")
+	assert.Equal(t, uint64(1), stats.Remote.Hits, "First read must hit the backend store")
+	assert.Equal(t, uint64(2), stats.Remote.Misses, "Missing key must miss the backend store twice")
+}
+
+func TestTypedGetMany(t *testing.T) {
+	i := newMemoryTest(t)
+	users := For[typedUser](i, testPrefix)
+	users.Set(t.Context(), "1", typedUser{ID: 1}, 0)
+
+	vals, misses, err := users.GetMany(t.Context(), []string{"1", "2"})
+	assert.NoError(t, err, "GetMany must be no error")
+	assert.Equal(t, map[string]typedUser{"1": {ID: 1}}, vals, "Values must be keyed by the given keys")
+	assert.Equal(t, []string{"2"}, misses, "Missing key must be reported")
+}
+
+func TestGetManyRedis(t *testing.T) {
+	i := newRedisTest(t)
+
+	keys := make([]string, 50)
+	for n := range keys {
+		keys[n] = fmt.Sprint("GetMany", n)
+		i.Set(t.Context(), keys[n]).SetPrefix(testPrefix).SetTTL(time.Minute).Put(n)
+	}
+	var vals []int
+	res, err := i.GetMany(t.Context(), append(keys, "GetManyMissing"), testPrefix).Pull(&vals)
+	assert.NoError(t, err, "Pull must be no error")
+	assert.Equal(t, []string{"GetManyMissing"}, res.Misses, "Missing key must be reported")
+	for n := range keys {
+		assert.Equal(t, n, vals[n], "Values must be in the same order as the keys")
+	}
+}
@@ -6,100 +6,238 @@ import (
 	"fmt"
 	"reflect"
 	"strconv"
-	"strings"
+	"time"
 
 	"github.com/qoinlyid/qore"
-	"github.com/redis/go-redis/v9"
 	"github.com/vmihailenco/msgpack/v5"
 )
 
-// open is helper function to open redis connection based on appropriate client.
+// open is helper function to open the backend store based on config.
 func (i *Instance) open() error {
-	var addrs []string
-
-	// Read field "Addresses" first from config, means the firt & priority is using redis standalone
-	// or cluster mode.
-	if !qore.ValidationIsEmpty(i.cfg.Addresses) {
-		i.clustering = strings.Contains(i.cfg.Addresses, ",")
-		for addr := range strings.SplitSeq(i.cfg.Addresses, ",") {
-			if len(strings.TrimSpace(addr)) > 0 {
-				addrs = append(addrs, addr)
-			}
-		}
-		if len(addrs) == 0 {
-			return errors.New("[cache] failed to open connection: redis.Addresses is empty")
+	if err := i.openStore(); err != nil {
+		return err
+	}
+	if rs, ok := i.store.(*redisStore); ok && i.logger != nil {
+		rs.logHookOnce.Do(func() {
+			rs.client.AddHook(&logHook{logger: i.logger, namespace: i.cfg.Namespace, failover: !rs.clustering})
+		})
+	}
+	if !validCompression(i.cfg.Compression) {
+		return fmt.Errorf("%w: %s", ErrUnknownCompression, i.cfg.Compression)
+	}
+	if i.codec == nil {
+		codec, err := codecByName(i.cfg.Codec)
+		if err != nil {
+			return err
 		}
+		i.codec = codec
+	}
+	keyring, err := loadKeyring(i.cfg)
+	if err != nil {
+		return err
+	}
+	i.keyring = keyring
 
-		// New client.
-		if i.clustering {
-			// Redis client cluster.
-			i.client = redis.NewClusterClient(&redis.ClusterOptions{
-				Addrs:      addrs,
-				Username:   i.cfg.Username,
-				Password:   i.cfg.Password,
-				ClientName: i.cfg.Namespace,
-			})
-		} else {
-			i.client = redis.NewClient(&redis.Options{
-				Addr:       addrs[0],
-				ClientName: i.cfg.Namespace,
-				Username:   i.cfg.Username,
-				Password:   i.cfg.Password,
-				DB:         i.cfg.DB,
-			})
+	// L1 tier.
+	if i.cfg.LocalEnabled && i.local == nil {
+		i.local = newLocalCache(i.cfg)
+	}
+	return i.startInvalidation()
+}
+
+// openStore opens the backend store based on config driver.
+func (i *Instance) openStore() error {
+	// Store already plugged in through SetStore.
+	if i.store != nil {
+		return nil
+	}
+
+	switch i.cfg.Driver {
+	case DriverMemory:
+		i.store = newMemoryStore(i.cfg.MemoryMaxEntries, i.cfg.MemoryCleanupInterval, LocalPolicyLRU)
+		return nil
+	case DriverRedis:
+		store, err := newRedisStore(i.cfg)
+		if err != nil {
+			return err
 		}
+		i.store = store
 		return nil
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownDriver, i.cfg.Driver)
 	}
+}
 
-	// If field "Addresses" empty read through field "SentinelAddresses", if any open redis using sentinel.
-	if !qore.ValidationIsEmpty(i.cfg.SentinelAddresses) {
-		for addr := range strings.SplitSeq(i.cfg.SentinelAddresses, ",") {
-			if len(strings.TrimSpace(addr)) > 0 {
-				addrs = append(addrs, addr)
-			}
+// fetch reads the raw value of the key from the L1 tier (if any & not skipped) then the backend store.
+func (i *Instance) fetch(ctx context.Context, key string, skipLocal bool) ([]byte, error) {
+	if i.local == nil {
+		return i.fetchRemote(ctx, key)
+	}
+	if !skipLocal {
+		if b, ok := i.local.get(key); ok {
+			i.stats.localHits.Add(1)
+			return b, nil
 		}
-		if len(addrs) == 0 {
-			return errors.New("[cache] failed to open connection: redis.SentinelAddresses is empty")
+		i.stats.localMisses.Add(1)
+	}
+
+	// Without invalidation, the local copy is only bounded by the local TTL.
+	inv := i.invalidation
+	if inv == nil {
+		b, err := i.fetchRemote(ctx, key)
+		if err == nil {
+			i.local.set(key, b, 0)
 		}
+		return b, err
+	}
+
+	// Keep the local copy only if no invalidation arrives while reading.
+	resets := inv.begin(key)
+	var (
+		b       []byte
+		err     error
+		tracked = true
+	)
+	if tr, ok := i.tracker(); ok {
+		b, tracked, err = tr.trackedGet(ctx, key)
+		i.countRemote(err)
+	} else {
+		b, err = i.fetchRemote(ctx, key)
+	}
+	if valid := inv.end(key, resets); err == nil && valid && tracked {
+		i.local.set(key, b, 0)
+	}
+	return b, err
+}
+
+// fetchRemote reads the raw value of the key from the backend store.
+func (i *Instance) fetchRemote(ctx context.Context, key string) ([]byte, error) {
+	b, err := i.store.Get(ctx, key)
+	i.countRemote(err)
+	return b, err
+}
 
-		i.clustering = i.cfg.SentinelCluster
-		sentOpts := &redis.FailoverOptions{
-			// Sentinel.
-			MasterName:       i.cfg.SentinelMaster,
-			SentinelAddrs:    addrs,
-			SentinelUsername: i.cfg.SentinelUsername,
-			SentinelPassword: i.cfg.SentinelPassword,
-			ClientName:       i.cfg.Namespace,
+// countRemote counts hit & miss of the backend store read.
+func (i *Instance) countRemote(err error) {
+	switch {
+	case err == nil:
+		i.stats.remoteHits.Add(1)
+	case isMiss(err):
+		i.stats.remoteMisses.Add(1)
+	}
+}
 
-			// Redis.
-			Username: i.cfg.Username,
-			Password: i.cfg.Password,
-			DB:       i.cfg.DB,
+// fetchMany reads the raw values of the keys from the L1 tier (if any & not skipped) then the backend store,
+// nil value means the key does not exist. With the client-side caching tracking the values are not kept
+// in the L1 tier, since the batch read is not tracked.
+func (i *Instance) fetchMany(ctx context.Context, keys []string, skipLocal bool) ([][]byte, error) {
+	vals := make([][]byte, len(keys))
+	missing := make([]int, 0, len(keys))
+	for idx, key := range keys {
+		if i.local != nil && !skipLocal {
+			if b, ok := i.local.get(key); ok {
+				i.stats.localHits.Add(1)
+				vals[idx] = b
+				continue
+			}
+			i.stats.localMisses.Add(1)
 		}
+		missing = append(missing, idx)
+	}
+	if len(missing) == 0 {
+		return vals, nil
+	}
 
-		// Sentinel cluster?.
-		if i.clustering {
-			sentOpts.RouteByLatency = true
-			i.client = redis.NewFailoverClusterClient(sentOpts)
+	// Keep the local copy only if no invalidation arrives while reading.
+	remoteKeys := make([]string, len(missing))
+	for n, idx := range missing {
+		remoteKeys[n] = keys[idx]
+	}
+	_, tracking := i.tracker()
+	keepLocal := i.local != nil && !tracking
+	inv := i.invalidation
+	var resets []uint64
+	if keepLocal && inv != nil {
+		resets = make([]uint64, len(remoteKeys))
+		for n, key := range remoteKeys {
+			resets[n] = inv.begin(key)
+		}
+	}
+	remote, err := i.fetchRemoteMany(ctx, remoteKeys)
+	for n, key := range remoteKeys {
+		valid := keepLocal
+		if resets != nil {
+			valid = inv.end(key, resets[n]) && valid
+		}
+		if err == nil && valid && remote[n] != nil {
+			i.local.set(key, remote[n], 0)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	for n, idx := range missing {
+		vals[idx] = remote[n]
+	}
+	return vals, nil
+}
+
+// fetchRemoteMany reads the raw values of the keys from the backend store in a single round trip
+// when the store implements Batcher, otherwise one by one.
+func (i *Instance) fetchRemoteMany(ctx context.Context, keys []string) ([][]byte, error) {
+	var vals [][]byte
+	if batcher, ok := i.store.(Batcher); ok {
+		b, err := batcher.MGet(ctx, keys...)
+		if err != nil {
+			return nil, err
+		}
+		vals = b
+	} else {
+		vals = make([][]byte, len(keys))
+		for idx, key := range keys {
+			b, err := i.store.Get(ctx, key)
+			if isMiss(err) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			vals[idx] = b
+		}
+	}
+	for _, b := range vals {
+		if b == nil {
+			i.stats.remoteMisses.Add(1)
 		} else {
-			i.client = redis.NewFailoverClient(sentOpts)
+			i.stats.remoteHits.Add(1)
 		}
-		return nil
 	}
+	return vals, nil
+}
 
-	// Fallback error.
-	return errors.New("at least one of redis addresses and sentinel addresses must be defines")
+// storeLocal keeps the written value in the L1 tier. With the client-side caching tracking the local copy
+// is evicted instead, since the server only tracks the keys read through the tracked connection.
+func (i *Instance) storeLocal(key string, val []byte, ttl time.Duration) {
+	if i.local == nil {
+		return
+	}
+	if _, ok := i.tracker(); ok {
+		i.local.del(key)
+		return
+	}
+	i.local.set(key, val, ttl)
 }
 
 type base struct {
 	ctx    context.Context
 	cancel context.CancelFunc
 	key    string
+	prefix string
 }
 
-func (i *Instance) validateClient() error {
-	if i.client == nil {
-		return ErrClientNil
+func (i *Instance) validateStore() error {
+	if i.store == nil {
+		return ErrStoreNil
 	}
 	return nil
 }
@@ -256,87 +394,81 @@ func decoder(data []byte, out any) error {
 	}
 }
 
-// set helper to store the value into redis storage.
-func (i *Instance) set(set *setter, val any, borrow ...bool) (redis.UniversalClient, error) {
+// set helper to store the value into the backend store.
+func (i *Instance) set(set *setter, val any) error {
+	defer set.cleanup()
+	op := &operation{name: OpSet, key: set.key, prefix: set.prefix, ttl: set.ttl}
+	return i.instrument(set.ctx, op, func(ctx context.Context) error {
+		set.ctx = ctx
+		return i.setValue(set, op, val)
+	})
+}
+
+// setValue encodes & writes the value of the set operation.
+func (i *Instance) setValue(set *setter, op *operation, val any) error {
 	// Validate.
-	if err := i.validateClient(); err != nil {
-		set.cleanup()
-		return nil, err
+	if err := i.validateStore(); err != nil {
+		return err
 	}
 	if qore.ValidationIsEmpty(set.key) {
-		set.cleanup()
-		return nil, ErrEmptyKey
+		return ErrEmptyKey
 	}
+	key := set.key
 	set.key = i.cfg.Namespace + DefaultKeySeparator + set.key
 
-	// Want borrow client?
-	if len(borrow) > 0 {
-		if borrow[0] {
-			return i.client, nil
-		}
-	}
-	defer set.cleanup()
-
 	// Exec.
-	encoded, err := encoder(val)
+	codec := i.valueCodec(set.codec)
+	encoded, err := codec.Marshal(val)
 	if err != nil {
-		return nil, fmt.Errorf("failed to encode value %T: %w", val, err)
+		return opError("set", key, fmt.Errorf("failed to encode value %T: %w", val, err))
 	}
-	return nil, i.client.Set(set.ctx, set.key, encoded, set.ttl).Err()
-}
-
-// getRemember helper to get default value and set it to the redis storage.
-func (i *Instance) getRemember(
-	get *getter,
-	out any,
-	rem RememberFn,
-) (next bool, err error) {
-	// Check is exist.
-	exist := i.Has(get.ctx, get.key)
-	if exist {
-		return true, nil
-	}
-
-	// Check out must be pointer.
-	outVal := reflect.ValueOf(out)
-	if outVal.Kind() != reflect.Ptr || outVal.IsNil() {
-		return false, errors.New("out must be a non-nil pointer")
-	}
-
-	// Call closure function to get the default value.
-	forever, val, err := rem()
+	framed, err := encodeFrame(encoded, i.frameOptions(set.key, codec, set.version, set.meta, set.compression))
 	if err != nil {
-		return false, err
+		return opError("set", key, err)
 	}
-
-	// Make sure val can be assigned to the out.
-	valVal := reflect.ValueOf(val)
-	if !valVal.Type().AssignableTo(outVal.Elem().Type()) {
-		return false, fmt.Errorf(
-			"cannot assign value of type %s to out of type %s",
-			valVal.Type(), outVal.Elem().Type(),
-		)
+	op.size = len(framed)
+	if err := i.write(set.ctx, set.key, framed, set.ttl); err != nil {
+		return opError("set", key, err)
 	}
+	return nil
+}
 
-	// Store value into cache.
-	set := i.Set(get.ctx, get.key)
-	if forever {
-		if _, err := set.PutForever(val); err != nil {
-			return false, err
+// rateLimitOnce helper to store the rate limit key only if it does not exist.
+func (i *Instance) rateLimitOnce(set *setter) (allowed bool, err error) {
+	defer set.cleanup()
+	op := &operation{name: OpRateLimit, key: set.key, prefix: set.prefix, ttl: set.ttl}
+	err = i.instrument(set.ctx, op, func(ctx context.Context) error {
+		// Validate.
+		if err := i.validateStore(); err != nil {
+			return err
 		}
-	} else {
-		if _, err := set.Put(val); err != nil {
-			return false, err
+		if qore.ValidationIsEmpty(set.key) {
+			return ErrEmptyKey
 		}
-	}
 
-	// Ok.
-	outVal.Elem().Set(valVal)
-	return false, nil
+		// Perform SET with NX (only if key doesn't exist) and EX (expire after TTL).
+		var e error
+		allowed, e = i.store.SetNX(ctx, i.cfg.Namespace+DefaultKeySeparator+set.key, []byte("1"), set.ttl)
+		return e
+	})
+	return
+}
+
+// write stores the raw value of the key into the backend store & keeps the L1 tier coherent.
+func (i *Instance) write(ctx context.Context, key string, b []byte, ttl time.Duration) error {
+	if err := i.store.Set(ctx, key, b, ttl); err != nil {
+		if i.local != nil {
+			i.local.del(key)
+		}
+		return err
+	}
+	i.storeLocal(key, b, ttl)
+	i.publishInvalidation(ctx, key)
+	return nil
 }
 
-// get helper to retrieve the value from redis storage.
-func (i *Instance) get(get *getter, out any, rem ...RememberFn) error {
+// get helper to retrieve the value from the backend store.
+func (i *Instance) get(get *getter, out any, rem ...RememberContextFn) error {
 	defer func() {
 		if get.cancel != nil {
 			get.cancel()
@@ -344,41 +476,70 @@ func (i *Instance) get(get *getter, out any, rem ...RememberFn) error {
 		// Zero out all fields to help GC or prepare for reuse
 		*get = getter{}
 	}()
+	op := &operation{name: OpGet, key: get.key, prefix: get.prefix}
+	if len(rem) > 0 && rem[0] != nil {
+		op.remember, op.ttl = true, get.ttl
+	}
+	return i.instrument(get.ctx, op, func(ctx context.Context) error {
+		get.ctx = ctx
+		return i.getValue(get, op, out, rem...)
+	})
+}
 
+// getValue reads & decodes the value of the get operation.
+func (i *Instance) getValue(get *getter, op *operation, out any, rem ...RememberContextFn) error {
 	// Validate.
 	if qore.ValidationIsEmpty(get.key) {
 		return ErrEmptyKey
 	}
-	if err := i.validateClient(); err != nil {
+	if err := i.validateStore(); err != nil {
 		return err
 	}
 
-	// Remember: get default value and store it to the redis storage.
-	if len(rem) > 0 {
-		if rem[0] != nil {
-			next, err := i.getRemember(get, out, rem[0])
-			if err != nil {
-				return err
-			} else if !next {
-				return nil
-			}
-		}
-	}
-
 	// Exec.
+	key := get.key
 	get.key = i.cfg.Namespace + DefaultKeySeparator + get.key
-	b, err := i.client.Get(get.ctx, get.key).Bytes()
+	b, err := i.fetch(get.ctx, get.key, get.skipLocal)
+	if isMiss(err) {
+		op.misses = 1
+	}
 	if err != nil {
+		// Remember: get default value and store it to the backend store.
+		if len(rem) > 0 && rem[0] != nil && isMiss(err) {
+			return i.remember(get, key, out, rem[0])
+		}
+		return opError("get", key, err)
+	}
+	op.hits, op.size = 1, len(b)
+	codec := i.valueCodec(get.codec)
+	payload, meta, err := decodeFrame(b, i.frameOptions(get.key, codec, get.version, nil, ""))
+	switch {
+	case errors.Is(err, ErrNotFound):
 		return err
+	case errors.Is(err, errInvalidEnvelope):
+		// Stale schema or corrupted value, never return it.
+		op.hits, op.misses = 0, 1
+		i.dropInvalid(get.ctx, get.key)
+		if len(rem) > 0 && rem[0] != nil {
+			return i.remember(get, key, out, rem[0])
+		}
+		return &OpError{Op: "get", Key: key, Kind: ErrCacheMiss, Err: err}
+	case err == nil:
+		err = codec.Unmarshal(payload, out)
 	}
-	err = decoder(b, out)
 	if err != nil {
-		return fmt.Errorf("failed to decode value to %T: %w", out, err)
+		return decodeError("get", key, out, err)
+	}
+
+	// Remember: serve the stale value & refresh it in the background.
+	if len(rem) > 0 && rem[0] != nil && meta.stale(time.Now(), get.beta) {
+		i.refresh(get, key, reflect.TypeOf(out).Elem(), rem[0])
 	}
 	return nil
 }
 
-func (i *Instance) del(del *deleter) (int64, error) {
+// del helper to delete the value from the backend store.
+func (i *Instance) del(del *deleter) (count int64, err error) {
 	defer func() {
 		if del.cancel != nil {
 			del.cancel()
@@ -386,16 +547,33 @@ func (i *Instance) del(del *deleter) (int64, error) {
 		// Zero out all fields to help GC or prepare for reuse
 		*del = deleter{}
 	}()
+	op := &operation{name: OpDel, key: del.key, prefix: del.prefix}
+	err = i.instrument(del.ctx, op, func(ctx context.Context) error {
+		del.ctx = ctx
+		var e error
+		count, e = i.delValue(del)
+		return e
+	})
+	return
+}
 
+// delValue deletes the value of the del operation.
+func (i *Instance) delValue(del *deleter) (int64, error) {
 	// Validate.
 	if qore.ValidationIsEmpty(del.key) {
 		return 0, ErrEmptyKey
 	}
-	if err := i.validateClient(); err != nil {
+	if err := i.validateStore(); err != nil {
 		return 0, err
 	}
 
 	// Exec.
+	key := del.key
 	del.key = i.cfg.Namespace + DefaultKeySeparator + del.key
-	return i.client.Del(context.Background(), del.key).Result()
+	count, err := i.store.Del(del.ctx, del.key)
+	if i.local != nil {
+		i.local.del(del.key)
+	}
+	i.publishInvalidation(del.ctx, del.key)
+	return count, opError("del", key, err)
 }
@@ -0,0 +1,52 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// operation is the cache operation passed through the instrumentation, the measurements
+// are filled while it runs.
+type operation struct {
+	name     string
+	key      string   // Key without the namespace, including the prefix.
+	keys     []string // Keys of the batch operation.
+	prefix   string
+	remember bool
+	ttl      time.Duration
+	hits     int
+	misses   int
+	size     int
+}
+
+// instrument runs the operation through the interceptors within its span (if tracing is enabled),
+// reports it to the metrics hook (if any) & logs it when it fails to decode or is slow.
+func (i *Instance) instrument(ctx context.Context, op *operation, fn Handler) error {
+	if i.metrics == nil && i.tracing == nil && i.logger == nil {
+		return i.intercept(ctx, op, fn)
+	}
+	start := time.Now()
+	ctx, span := i.startSpan(ctx, op)
+	err := i.intercept(ctx, op, fn)
+	opErr := err
+	if isMiss(err) || errors.Is(err, ErrNotFound) {
+		opErr = nil
+	}
+	elapsed := time.Since(start)
+	i.endSpan(span, op, opErr)
+	i.logOperation(ctx, op, elapsed, opErr)
+	if i.metrics != nil {
+		i.metrics.ObserveOperation(ctx, Observation{
+			Namespace: i.cfg.Namespace,
+			Op:        op.name,
+			Prefix:    op.prefix,
+			Hits:      op.hits,
+			Misses:    op.misses,
+			Size:      op.size,
+			Duration:  elapsed,
+			Err:       opErr,
+		})
+	}
+	return err
+}
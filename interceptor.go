@@ -0,0 +1,73 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Op describes the cache operation passed to the interceptors.
+type Op struct {
+	// Name is the operation, one of OpGet, OpSet, OpDel, OpHas, OpScan, OpRateLimit,
+	// OpAcquire, OpExtend or OpRelease.
+	Name string
+
+	// Key is the key without the namespace, including the prefix. Empty for the batch & scan operations.
+	Key string
+
+	// Keys is the keys of the batch operation without the namespace, including the prefix.
+	Keys []string
+
+	// Prefix is the per call key prefix, or the scanned prefix of OpScan.
+	Prefix string
+
+	// TTL is the time-to-live of the written value, zero if none.
+	TTL time.Duration
+
+	// Remember reports whether the get operation is called by Remember.
+	Remember bool
+}
+
+// Handler runs the cache operation, or the next interceptor of the chain.
+type Handler func(ctx context.Context) error
+
+// Interceptor wraps every cache operation, it must call next to run the operation.
+// It is the extension point for logging, auditing or fault injection, e.g.
+//
+//	func(ctx context.Context, op cache.Op, next cache.Handler) error {
+//		if op.Name == cache.OpGet && rand.Float64() < 0.01 {
+//			return cache.ErrBackendUnavailable
+//		}
+//		return next(ctx)
+//	}
+type Interceptor func(ctx context.Context, op Op, next Handler) error
+
+// Use registers the interceptors wrapping every operation issued by Put, Pull, Remember, Perform, Has,
+// GetAllKeys, the rate limiters, the semaphore & the batch operations. The first registered interceptor is the outermost,
+// all of them run within the operation span & metrics. It must be called before the instance is used.
+//
+//	cache := cache.New().Use(logging, audit)
+func (i *Instance) Use(interceptors ...Interceptor) *Instance {
+	i.interceptors = append(i.interceptors, interceptors...)
+	return i
+}
+
+// intercept runs the operation through the interceptor chain.
+func (i *Instance) intercept(ctx context.Context, op *operation, fn Handler) error {
+	if len(i.interceptors) == 0 {
+		return fn(ctx)
+	}
+	desc := Op{
+		Name:     op.name,
+		Key:      op.key,
+		Keys:     op.keys,
+		Prefix:   op.prefix,
+		TTL:      op.ttl,
+		Remember: op.remember,
+	}
+	next := fn
+	for n := len(i.interceptors) - 1; n >= 0; n-- {
+		interceptor, inner := i.interceptors[n], next
+		next = func(ctx context.Context) error { return interceptor(ctx, desc, inner) }
+	}
+	return next(ctx)
+}
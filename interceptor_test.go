@@ -0,0 +1,83 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterceptorChain(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		calls []string
+		ops   []Op
+	)
+	record := func(name string) Interceptor {
+		return func(ctx context.Context, op Op, next Handler) error {
+			mu.Lock()
+			calls = append(calls, name+":"+op.Name)
+			if name == "outer" {
+				ops = append(ops, op)
+			}
+			mu.Unlock()
+			return next(ctx)
+		}
+	}
+	i := newMemoryTest(t).Use(record("outer"), record("inner"))
+	ctx := t.Context()
+
+	_, err := i.Set(ctx, testKey).SetPrefix(testPrefix).SetTTL(time.Minute).Put(testValue)
+	assert.NoError(t, err, "Put must be no error")
+	assert.Equal(t, []string{"outer:set", "inner:set"}, calls, "Interceptors must run in the registration order")
+	assert.Equal(t, Op{Name: OpSet, Key: testPrefix + DefaultKeySeparator + testKey, Prefix: testPrefix, TTL: time.Minute}, ops[0], "Op must describe the operation")
+
+	var out string
+	i.Get(ctx, testKey, testPrefix).Pull(&out)
+	i.Has(ctx, testKey, testPrefix)
+	i.GetAllKeys(ctx, testPrefix)
+	i.Delete(ctx, testKey, testPrefix).Perform()
+	i.Set(ctx, testKey).RateLimitOnce(time.Minute)
+	i.GetMany(ctx, []string{"1", "2"}, testPrefix).Pull(&map[string]string{})
+
+	names := make([]string, len(ops))
+	for n, op := range ops {
+		names[n] = op.Name
+	}
+	assert.Equal(t, []string{OpSet, OpGet, OpHas, OpScan, OpDel, OpRateLimit, OpGet}, names, "Every operation must be intercepted")
+	assert.Equal(t, []string{testPrefix + DefaultKeySeparator + "1", testPrefix + DefaultKeySeparator + "2"}, ops[len(ops)-1].Keys, "Batch keys must be described")
+}
+
+func TestInterceptorFaultInjection(t *testing.T) {
+	i := newMemoryTest(t).Use(func(ctx context.Context, op Op, next Handler) error {
+		if op.Name == OpGet {
+			return ErrBackendUnavailable
+		}
+		return next(ctx)
+	})
+	_, err := i.Set(t.Context(), testKey).Put(testValue)
+	assert.NoError(t, err, "Put must not be intercepted")
+
+	var out string
+	err = i.Get(t.Context(), testKey).Pull(&out)
+	assert.ErrorIs(t, err, ErrBackendUnavailable, "Injected fault must be returned")
+	assert.Empty(t, out, "Operation must not run")
+}
+
+func TestInterceptorRemember(t *testing.T) {
+	var remember bool
+	i := newMemoryTest(t).Use(func(ctx context.Context, op Op, next Handler) error {
+		if op.Name == OpGet {
+			remember = op.Remember
+		}
+		return next(ctx)
+	})
+	var out int
+	err := i.Get(t.Context(), testKeyRemember).Remember(&out, func() (bool, any, error) {
+		return false, testValueRemember, nil
+	})
+	assert.NoError(t, err, "Remember must be no error")
+	assert.True(t, remember, "Op must tell the Remember call")
+}
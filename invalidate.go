@@ -0,0 +1,244 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"sync"
+)
+
+// tracker is implemented by Store that supports server-assisted client-side caching.
+type tracker interface {
+	// track blocks & calls onInvalidate for the tracked keys changed in the server until ctx is done,
+	// onReset is called whenever the invalidation may be lost. Empty bcastPrefix means default mode,
+	// only the keys read through trackedGet are tracked.
+	track(ctx context.Context, bcastPrefix string, onInvalidate func(keys []string), onReset func()) error
+
+	// trackedGet reads the key & reports whether the key is tracked by the server.
+	trackedGet(ctx context.Context, key string) (val []byte, tracked bool, err error)
+}
+
+// invalidation is the L1 tier invalidation subscription of the instance.
+type invalidation struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	// mu guards the in-flight backend reads, so a value read before its invalidation arrives
+	// is not kept in the L1 tier.
+	mu      sync.Mutex
+	pending map[string]*pendingRead
+	resets  uint64
+}
+
+// pendingRead is an in-flight backend read of a key.
+type pendingRead struct {
+	refs  int
+	dirty bool
+}
+
+// begin registers in-flight backend read of the key.
+func (inv *invalidation) begin(key string) uint64 {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	p, ok := inv.pending[key]
+	if !ok {
+		p = &pendingRead{}
+		inv.pending[key] = p
+	}
+	p.refs++
+	return inv.resets
+}
+
+// end unregisters in-flight backend read of the key, it reports whether the read value is still valid
+// to be kept in the L1 tier.
+func (inv *invalidation) end(key string, resets uint64) bool {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	p := inv.pending[key]
+	valid := !p.dirty && inv.resets == resets
+	if p.refs--; p.refs == 0 {
+		delete(inv.pending, key)
+	}
+	return valid
+}
+
+// invalidate marks the in-flight reads of the keys as dirty & evicts the local copy.
+func (inv *invalidation) invalidate(local *localCache, keys ...string) {
+	inv.mu.Lock()
+	for _, key := range keys {
+		if p, ok := inv.pending[key]; ok {
+			p.dirty = true
+		}
+	}
+	inv.mu.Unlock()
+	local.del(keys...)
+}
+
+// reset marks all in-flight reads as dirty & flushes the local tier.
+func (inv *invalidation) reset(local *localCache) {
+	inv.mu.Lock()
+	inv.resets++
+	inv.mu.Unlock()
+	local.flush()
+}
+
+// newInstanceID returns random identifier of the instance, it is used to ignore self invalidation.
+func newInstanceID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// invalidationChannel returns the namespace-scoped invalidation channel.
+func (i *Instance) invalidationChannel() string {
+	return i.cfg.Namespace + DefaultKeySeparator + KeyInvalidation
+}
+
+// notifier returns the store notifier when the pub/sub invalidation is used.
+func (i *Instance) notifier() (Notifier, bool) {
+	if i.cfg.LocalSync != LocalSyncPubSub {
+		return nil, false
+	}
+	notifier, ok := i.store.(Notifier)
+	return notifier, ok
+}
+
+// tracker returns the store tracker when the client-side caching tracking is used.
+func (i *Instance) tracker() (tracker, bool) {
+	if i.cfg.LocalSync != LocalSyncTracking && i.cfg.LocalSync != LocalSyncTrackingBCAST {
+		return nil, false
+	}
+	tracker, ok := i.store.(tracker)
+	return tracker, ok
+}
+
+// startInvalidation starts the L1 tier invalidation based on the config LocalSync, either subscribes
+// the invalidation channel or enables the client-side caching tracking.
+func (i *Instance) startInvalidation() error {
+	if i.local == nil || i.invalidation != nil {
+		return nil
+	}
+
+	var run func(ctx context.Context, inv *invalidation)
+	local := i.local
+	switch i.cfg.LocalSync {
+	case LocalSyncPubSub:
+		notifier, ok := i.notifier()
+		if !ok {
+			return nil
+		}
+		run = func(ctx context.Context, inv *invalidation) {
+			notifier.Subscribe(ctx, i.invalidationChannel(), func(payload []byte) {
+				origin, keys, ok := parseInvalidation(payload)
+				if !ok {
+					// Unknown payload, the keys cannot be trusted so flush the local tier.
+					inv.reset(local)
+					return
+				}
+				if origin == i.id {
+					return
+				}
+				if len(keys) == 0 {
+					inv.reset(local)
+					return
+				}
+				inv.invalidate(local, keys...)
+			}, func() {
+				// Subscription (re)established, the missed messages are unknown so flush the local tier.
+				inv.reset(local)
+			})
+		}
+	case LocalSyncTracking, LocalSyncTrackingBCAST:
+		tracker, ok := i.tracker()
+		if !ok {
+			return ErrTrackingNotSupported
+		}
+		var prefix string
+		if i.cfg.LocalSync == LocalSyncTrackingBCAST {
+			prefix = i.cfg.Namespace + DefaultKeySeparator
+		}
+		if rs, ok := tracker.(*redisStore); ok && rs.newClient == nil {
+			return ErrTrackingNotSupported
+		}
+		run = func(ctx context.Context, inv *invalidation) {
+			tracker.track(ctx, prefix, func(keys []string) {
+				inv.invalidate(local, keys...)
+			}, func() {
+				inv.reset(local)
+			})
+		}
+	default:
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	inv := &invalidation{
+		cancel:  cancel,
+		done:    make(chan struct{}),
+		pending: make(map[string]*pendingRead),
+	}
+	i.invalidation = inv
+	go func() {
+		defer close(inv.done)
+		run(ctx, inv)
+	}()
+	return nil
+}
+
+// stopInvalidation stops the L1 tier invalidation.
+func (i *Instance) stopInvalidation() {
+	if i.invalidation == nil {
+		return
+	}
+	i.invalidation.cancel()
+	<-i.invalidation.done
+	i.invalidation = nil
+}
+
+// publishInvalidation broadcasts the written or deleted keys to the other instances.
+// It is best effort, the other instances still bounded by the local TTL if the publish fails.
+func (i *Instance) publishInvalidation(ctx context.Context, keys ...string) {
+	notifier, ok := i.notifier()
+	if !ok || len(keys) == 0 {
+		return
+	}
+
+	notifier.Publish(ctx, i.invalidationChannel(), appendInvalidation(nil, i.id, keys...))
+}
+
+// appendInvalidation appends the invalidation payload, the origin & every key are prefixed by their
+// uvarint length so the keys may contain any byte.
+func appendInvalidation(b []byte, origin string, keys ...string) []byte {
+	b = binary.AppendUvarint(b, uint64(len(origin)))
+	b = append(b, origin...)
+	for _, key := range keys {
+		b = binary.AppendUvarint(b, uint64(len(key)))
+		b = append(b, key...)
+	}
+	return b
+}
+
+// parseInvalidation parses the payload of appendInvalidation, ok is false when the payload is malformed.
+func parseInvalidation(b []byte) (origin string, keys []string, ok bool) {
+	next := func() (string, bool) {
+		n, size := binary.Uvarint(b)
+		if size <= 0 || uint64(len(b)-size) < n {
+			return "", false
+		}
+		s := string(b[size : size+int(n)])
+		b = b[size+int(n):]
+		return s, true
+	}
+	if origin, ok = next(); !ok {
+		return "", nil, false
+	}
+	for len(b) > 0 {
+		key, ok := next()
+		if !ok {
+			return "", nil, false
+		}
+		keys = append(keys, key)
+	}
+	return origin, keys, true
+}
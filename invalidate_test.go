@@ -0,0 +1,167 @@
+package cache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/qoinlyid/qore"
+	"github.com/stretchr/testify/assert"
+)
+
+// newInvalidationTest returns two opened instances with L1 tier sharing the same memory store.
+func newInvalidationTest(t *testing.T) (*Instance, *Instance) {
+	t.Helper()
+	t.Setenv(qore.CONFIG_USED_KEY, "OS")
+	t.Setenv("CACHE_DRIVER", DriverMemory)
+	t.Setenv("CACHE_LOCAL_ENABLED", "true")
+	t.Setenv("CACHE_LOCAL_TTL", "1m")
+
+	store := newMemoryStore(0, 0, LocalPolicyLRU)
+	a, b := New().SetStore(store), New().SetStore(store)
+	for _, i := range []*Instance{a, b} {
+		if err := i.Open(); err != nil {
+			t.Fatalf("Open must be no error: %s", err)
+		}
+		t.Cleanup(func() { i.Close() })
+		waitInvalidation(t, i)
+	}
+	return a, b
+}
+
+// waitInvalidation waits until the invalidation of the instance is (re)established, it flushes the local
+// tier once ready.
+func waitInvalidation(t *testing.T, i *Instance) {
+	t.Helper()
+	inv := i.invalidation
+	assert.Eventually(t, func() bool {
+		inv.mu.Lock()
+		defer inv.mu.Unlock()
+		return inv.resets > 0
+	}, time.Second, time.Millisecond, "Invalidation must be ready")
+}
+
+func TestInvalidationChannel(t *testing.T) {
+	i := newLocalTest(t)
+	expected := i.cfg.Namespace + DefaultKeySeparator + KeyInvalidation
+	assert.Equal(t, LocalSyncPubSub, i.cfg.LocalSync, "LocalSync should be pubsub when L1 tier enabled")
+	assert.Equal(t, expected, i.invalidationChannel(), fmt.Sprintf("Channel should be %s", expected))
+}
+
+func TestInvalidationOnPut(t *testing.T) {
+	a, b := newInvalidationTest(t)
+	a.Set(t.Context(), testKey).SetPrefix(testPrefix).Put(testValue)
+
+	// Warm the L1 tier of b.
+	var val string
+	b.Get(t.Context(), testKey, testPrefix).Pull(&val)
+	assert.Equal(t, testValue, val, fmt.Sprintf("Value should be %s", testValue))
+
+	a.Set(t.Context(), testKey).SetPrefix(testPrefix).Put("changed")
+	b.Get(t.Context(), testKey, testPrefix).Pull(&val)
+	assert.Equal(t, "changed", val, "Local copy of b must be evicted after a Put")
+}
+
+func TestInvalidationOnDelete(t *testing.T) {
+	a, b := newInvalidationTest(t)
+	a.Set(t.Context(), testKey).SetPrefix(testPrefix).Put(testValue)
+
+	var val string
+	b.Get(t.Context(), testKey, testPrefix).Pull(&val)
+	a.Delete(t.Context(), testKey, testPrefix).Perform()
+	err := b.Get(t.Context(), testKey, testPrefix).Pull(&val)
+	assert.Error(t, err, "Local copy of b must be evicted after a Perform")
+}
+
+func TestInvalidationIgnoreSelf(t *testing.T) {
+	a, _ := newInvalidationTest(t)
+	a.Set(t.Context(), testKey).SetPrefix(testPrefix).Put(testValue)
+
+	var val string
+	a.Get(t.Context(), testKey, testPrefix).Pull(&val)
+	assert.Equal(t, uint64(1), a.Stats().Local.Hits, "Own invalidation must not evict the local copy")
+}
+
+func TestInvalidationFlushOnReset(t *testing.T) {
+	a, b := newInvalidationTest(t)
+	a.Set(t.Context(), testKey).SetPrefix(testPrefix).Put(testValue)
+
+	var val string
+	b.Get(t.Context(), testKey, testPrefix).Pull(&val)
+	b.stopInvalidation()
+	b.startInvalidation()
+	waitInvalidation(t, b)
+
+	b.Get(t.Context(), testKey, testPrefix).Pull(&val)
+	assert.Equal(t, uint64(0), b.Stats().Local.Hits, "Local tier must be flushed when re-subscribed")
+}
+
+func TestInvalidationPayload(t *testing.T) {
+	keys := []string{"ns:a", "ns:multi\nline", "", "ns:\x00"}
+	origin, got, ok := parseInvalidation(appendInvalidation(nil, "origin", keys...))
+	assert.True(t, ok, "Payload must be parsed")
+	assert.Equal(t, "origin", origin, "Origin must be kept")
+	assert.Equal(t, keys, got, "Keys with any byte must be kept")
+
+	origin, got, ok = parseInvalidation(appendInvalidation(nil, "origin"))
+	assert.True(t, ok, "Reset payload must be parsed")
+	assert.Equal(t, "origin", origin)
+	assert.Empty(t, got, "Reset payload must have no keys")
+
+	_, _, ok = parseInvalidation([]byte{10, 'a'})
+	assert.False(t, ok, "Truncated payload must be malformed")
+}
+
+func TestInvalidationPendingRead(t *testing.T) {
+	i := newLocalTest(t)
+	inv := i.invalidation
+	assert.NotNil(t, inv, "Invalidation must be started")
+
+	resets := inv.begin(testKey)
+	inv.invalidate(i.local, testKey)
+	assert.False(t, inv.end(testKey, resets), "Read invalidated while in-flight must not be kept locally")
+
+	resets = inv.begin(testKey)
+	inv.reset(i.local)
+	assert.False(t, inv.end(testKey, resets), "Read in-flight while reset must not be kept locally")
+
+	resets = inv.begin(testKey)
+	assert.True(t, inv.end(testKey, resets), "Read without invalidation must be kept locally")
+	assert.Empty(t, inv.pending, "Pending reads must be released")
+}
+
+func TestInvalidationTrackingNotSupported(t *testing.T) {
+	t.Setenv("CACHE_LOCAL_ENABLED", "true")
+	t.Setenv("CACHE_LOCAL_SYNC", LocalSyncTracking)
+	t.Setenv(qore.CONFIG_USED_KEY, "OS")
+	t.Setenv("CACHE_DRIVER", DriverMemory)
+	i := New()
+	err := i.Open()
+	defer i.Close()
+	assert.ErrorIs(t, err, ErrTrackingNotSupported, "Memory driver must not support tracking")
+}
+
+func TestInvalidationTracking(t *testing.T) {
+	t.Setenv("CACHE_LOCAL_ENABLED", "true")
+	t.Setenv("CACHE_LOCAL_SYNC", LocalSyncTracking)
+	i := newRedisTest(t)
+	waitInvalidation(t, i)
+
+	i.Set(t.Context(), testKey).SetPrefix(testPrefix).Put(testValue)
+	var val string
+	i.Get(t.Context(), testKey, testPrefix).Pull(&val)
+	i.Get(t.Context(), testKey, testPrefix).Pull(&val)
+	assert.Equal(t, uint64(1), i.Stats().Local.Hits, "Tracked read must be served locally")
+
+	// Write through another instance, the server pushes the invalidation.
+	other := New()
+	if !assert.NoError(t, other.Open(), "Open other instance must be no error") {
+		return
+	}
+	defer other.Close()
+	other.Set(t.Context(), testKey).SetPrefix(testPrefix).Put("changed")
+	assert.Eventually(t, func() bool {
+		i.Get(t.Context(), testKey, testPrefix).Pull(&val)
+		return val == "changed"
+	}, time.Second, 10*time.Millisecond, "Local copy must be invalidated by the server")
+}
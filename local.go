@@ -0,0 +1,96 @@
+package cache
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// localCache is the optional in-process L1 tier in front of the backend store.
+// Every entry is bounded by the local max TTL, so the staleness of the L1 tier is bounded as well.
+type localCache struct {
+	store  *memoryStore
+	maxTTL time.Duration
+}
+
+// newLocalCache creates L1 tier from the config.
+func newLocalCache(cfg *Config) *localCache {
+	return &localCache{
+		store:  newMemoryStore(cfg.LocalMaxEntries, cfg.LocalTTL, cfg.LocalPolicy),
+		maxTTL: cfg.LocalTTL,
+	}
+}
+
+// get returns the local copy of the key.
+func (l *localCache) get(key string) ([]byte, bool) {
+	b, err := l.store.Get(context.Background(), key)
+	if err != nil {
+		return nil, false
+	}
+	return b, true
+}
+
+// set stores the local copy of the key, ttl is clamped to the local max TTL.
+func (l *localCache) set(key string, val []byte, ttl time.Duration) {
+	if ttl <= 0 || ttl > l.maxTTL {
+		ttl = l.maxTTL
+	}
+	l.store.Set(context.Background(), key, val, ttl)
+}
+
+// del evicts the local copy of the keys.
+func (l *localCache) del(keys ...string) {
+	l.store.Del(context.Background(), keys...)
+}
+
+// flush evicts all local copies.
+func (l *localCache) flush() {
+	l.store.mu.Lock()
+	l.store.items = make(map[string]*memoryEntry)
+	l.store.mu.Unlock()
+}
+
+// close releases the L1 tier.
+func (l *localCache) close() {
+	l.store.Close()
+}
+
+// TierStats defines hit & miss counters of a cache tier.
+type TierStats struct {
+	Hits   uint64
+	Misses uint64
+}
+
+// Stats defines hit & miss counters per cache tier.
+type Stats struct {
+	// Local is the counters of the in-process L1 tier.
+	Local TierStats
+
+	// Remote is the counters of the backend store.
+	Remote TierStats
+}
+
+// stats holds the hit & miss counters.
+type stats struct {
+	localHits    atomic.Uint64
+	localMisses  atomic.Uint64
+	remoteHits   atomic.Uint64
+	remoteMisses atomic.Uint64
+}
+
+// Stats returns hit & miss counters per cache tier since the instance was created.
+//
+//	stats := cache.Stats()
+//	log.Println(stats.Local.Hits, stats.Remote.Misses)
+func (i *Instance) Stats() Stats {
+	return Stats{
+		Local: TierStats{
+			Hits:   i.stats.localHits.Load(),
+			Misses: i.stats.localMisses.Load(),
+		},
+		Remote: TierStats{
+			Hits:   i.stats.remoteHits.Load(),
+			Misses: i.stats.remoteMisses.Load(),
+		},
+	}
+}
@@ -0,0 +1,81 @@
+package cache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newLocalTest returns opened instance using memory driver with L1 tier enabled.
+func newLocalTest(t *testing.T) *Instance {
+	t.Helper()
+	t.Setenv("CACHE_LOCAL_ENABLED", "true")
+	t.Setenv("CACHE_LOCAL_TTL", "1s")
+	return newMemoryTest(t)
+}
+
+func TestLocalGetHit(t *testing.T) {
+	i := newLocalTest(t)
+	assert.NotNil(t, i.local, "L1 tier must be enabled")
+
+	_, err := i.Set(t.Context(), testKey).SetPrefix(testPrefix).Put(testValue)
+	assert.NoError(t, err, "Put must be no error")
+
+	var val string
+	err = i.Get(t.Context(), testKey, testPrefix).Pull(&val)
+	assert.NoError(t, err, "Pull must be no error")
+	assert.Equal(t, testValue, val, fmt.Sprintf("Value should be %s", testValue))
+
+	stats := i.Stats()
+	assert.Equal(t, uint64(1), stats.Local.Hits, "Pull after Put must hit the L1 tier")
+	assert.Equal(t, uint64(0), stats.Remote.Hits, "Pull after Put must not hit the backend store")
+}
+
+func TestLocalSkipLocal(t *testing.T) {
+	i := newLocalTest(t)
+	i.Set(t.Context(), testKey).SetPrefix(testPrefix).Put(testValue)
+
+	var val string
+	err := i.Get(t.Context(), testKey, testPrefix).SkipLocal().Pull(&val)
+	assert.NoError(t, err, "Pull must be no error")
+	assert.Equal(t, testValue, val, fmt.Sprintf("Value should be %s", testValue))
+
+	stats := i.Stats()
+	assert.Equal(t, uint64(0), stats.Local.Hits+stats.Local.Misses, "SkipLocal must bypass the L1 tier")
+	assert.Equal(t, uint64(1), stats.Remote.Hits, "SkipLocal must hit the backend store")
+}
+
+func TestLocalStaleBoundedByTTL(t *testing.T) {
+	i := newLocalTest(t)
+	i.local.maxTTL = 20 * time.Millisecond
+	i.Set(t.Context(), testKey).SetPrefix(testPrefix).Put(testValue)
+
+	// Write to the backend store directly, bypassing the L1 tier.
+	key := i.cfg.Namespace + DefaultKeySeparator + testPrefix + DefaultKeySeparator + testKey
+	i.store.Set(t.Context(), key, []byte("changed"), time.Minute)
+
+	var val string
+	i.Get(t.Context(), testKey, testPrefix).Pull(&val)
+	assert.Equal(t, testValue, val, "L1 tier must serve the local copy")
+
+	time.Sleep(30 * time.Millisecond)
+	i.Get(t.Context(), testKey, testPrefix).Pull(&val)
+	assert.Equal(t, "changed", val, "L1 tier must expire after the local TTL")
+}
+
+func TestLocalDelete(t *testing.T) {
+	i := newLocalTest(t)
+	i.Set(t.Context(), testKey).SetPrefix(testPrefix).Put(testValue)
+
+	_, err := i.Delete(t.Context(), testKey, testPrefix).Perform()
+	assert.NoError(t, err, "Perform must be no error")
+
+	var val string
+	err = i.Get(t.Context(), testKey, testPrefix).Pull(&val)
+	assert.Error(t, err, "Pull deleted key must be error")
+	stats := i.Stats()
+	assert.Equal(t, uint64(1), stats.Local.Misses, "Deleted key must miss the L1 tier")
+	assert.Equal(t, uint64(1), stats.Remote.Misses, "Deleted key must miss the backend store")
+}
@@ -0,0 +1,124 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// SetLogger plugs the structured logger of the instance. It logs the connection lifecycle (open, close,
+// redis dial failures & master changes), the decode failures & the operations slower than the config
+// SlowThreshold. Nothing is logged without the logger.
+//
+//	cache := cache.New().SetLogger(slog.Default())
+func (i *Instance) SetLogger(logger *slog.Logger) *Instance {
+	i.logger = logger
+	return i
+}
+
+// logKey returns the key attributes of the log record based on the config LogKeys.
+func (i *Instance) logKey(key, prefix string) []slog.Attr {
+	var attrs []slog.Attr
+	if prefix != "" {
+		attrs = append(attrs, slog.String("prefix", prefix))
+	}
+	if key == "" {
+		return attrs
+	}
+	switch i.cfg.LogKeys {
+	case LogKeysFull:
+		attrs = append(attrs, slog.String("key", key))
+	case LogKeysHash:
+		sum := sha256.Sum256([]byte(key))
+		attrs = append(attrs, slog.String("key_hash", hex.EncodeToString(sum[:8])))
+	}
+	return attrs
+}
+
+// logOperation logs the decode failure & the slow operation.
+func (i *Instance) logOperation(ctx context.Context, op *operation, elapsed time.Duration, err error) {
+	if i.logger == nil {
+		return
+	}
+	if errors.Is(err, ErrDecode) || errors.Is(err, ErrDecrypt) {
+		i.logDecode(ctx, op.key, op.prefix, err)
+	}
+	if i.cfg.SlowThreshold <= 0 || elapsed < i.cfg.SlowThreshold {
+		return
+	}
+	attrs := append([]slog.Attr{
+		slog.String("namespace", i.cfg.Namespace),
+		slog.String("op", op.name),
+		slog.Duration("duration", elapsed),
+		slog.Int("size", op.size),
+	}, i.logKey(op.key, op.prefix)...)
+	if len(op.keys) > 0 {
+		attrs = append(attrs, slog.Int("keys", len(op.keys)))
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	i.logger.LogAttrs(ctx, slog.LevelWarn, "cache slow operation", attrs...)
+}
+
+// logDecode logs the value of the key that cannot be decoded.
+func (i *Instance) logDecode(ctx context.Context, key, prefix string, err error) {
+	if i.logger == nil {
+		return
+	}
+	attrs := append([]slog.Attr{
+		slog.String("namespace", i.cfg.Namespace),
+		slog.String("type", ErrorType(err)),
+		slog.String("error", err.Error()),
+	}, i.logKey(key, prefix)...)
+	i.logger.LogAttrs(ctx, slog.LevelError, "cache decode failed", attrs...)
+}
+
+// logHook is the redis hook logging the dial failures & the redis master address changes.
+type logHook struct {
+	logger    *slog.Logger
+	namespace string
+	failover  bool // Whether the address change is logged, only for the single master client.
+
+	mu   sync.Mutex
+	addr string
+}
+
+// DialHook implements redis.Hook.
+func (h *logHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := next(ctx, network, addr)
+		if err != nil {
+			h.logger.LogAttrs(ctx, slog.LevelWarn, "cache redis dial failed",
+				slog.String("namespace", h.namespace), slog.String("addr", addr), slog.String("error", err.Error()))
+			return conn, err
+		}
+		if !h.failover {
+			return conn, err
+		}
+		h.mu.Lock()
+		prev := h.addr
+		h.addr = addr
+		h.mu.Unlock()
+		if prev != "" && prev != addr {
+			h.logger.LogAttrs(ctx, slog.LevelWarn, "cache redis master changed",
+				slog.String("namespace", h.namespace), slog.String("from", prev), slog.String("to", addr))
+		}
+		return conn, err
+	}
+}
+
+// ProcessHook implements redis.Hook.
+func (h *logHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook { return next }
+
+// ProcessPipelineHook implements redis.Hook.
+func (h *logHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
@@ -0,0 +1,169 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qoinlyid/qore"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+// newLoggingTest returns an opened memory instance logging JSON records to the returned buffer.
+func newLoggingTest(t *testing.T, slow time.Duration, keys string) (*Instance, *bytes.Buffer) {
+	t.Helper()
+	t.Setenv("CACHE_SLOW_THRESHOLD", slow.String())
+	t.Setenv("CACHE_LOG_KEYS", keys)
+	buf := new(bytes.Buffer)
+	i := newMemoryTest(t).SetLogger(slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	return i, buf
+}
+
+// logRecords parses the JSON records of the buffer.
+func logRecords(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var records []map[string]any
+	for line := range strings.SplitSeq(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		record := make(map[string]any)
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("Log record must be JSON: %s", err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestLoggingConfig(t *testing.T) {
+	t.Setenv("CACHE_LOG_KEYS", " HASH ")
+	assert.Equal(t, LogKeysHash, loadConfig().LogKeys, "LogKeys must be normalized")
+	t.Setenv("CACHE_LOG_KEYS", "unknown")
+	assert.Equal(t, LogKeysPrefix, loadConfig().LogKeys, "Unknown LogKeys must fall back to prefix")
+}
+
+func TestLoggingSlowOperation(t *testing.T) {
+	i, buf := newLoggingTest(t, time.Nanosecond, LogKeysPrefix)
+	_, err := i.Set(t.Context(), testKey).SetPrefix(testPrefix).Put(testValue)
+	assert.NoError(t, err, "Put must be no error")
+
+	records := logRecords(t, buf)
+	if assert.Len(t, records, 1, "Slow operation must be logged") {
+		record := records[0]
+		assert.Equal(t, "cache slow operation", record["msg"])
+		assert.Equal(t, "WARN", record["level"])
+		assert.Equal(t, OpSet, record["op"], "Record must carry the op")
+		assert.Equal(t, testPrefix, record["prefix"], "Record must carry the key prefix")
+		assert.Equal(t, float64(len(testValue)), record["size"], "Record must carry the payload size")
+		assert.Contains(t, record, "duration", "Record must carry the duration")
+		assert.NotContains(t, record, "key", "Record must not carry the raw key by default")
+		assert.NotContains(t, record, "key_hash", "Record must not carry the key hash by default")
+	}
+}
+
+func TestLoggingSlowThresholdDisabled(t *testing.T) {
+	i, buf := newLoggingTest(t, 0, LogKeysPrefix)
+	i.Set(t.Context(), testKey).SetPrefix(testPrefix).Put(testValue)
+	var out string
+	i.Get(t.Context(), testKey, testPrefix).Pull(&out)
+	assert.Empty(t, buf.String(), "Nothing must be logged without the slow threshold")
+}
+
+func TestLoggingKeyRedaction(t *testing.T) {
+	i, buf := newLoggingTest(t, time.Nanosecond, LogKeysHash)
+	i.Set(t.Context(), testKey).SetPrefix(testPrefix).Put(testValue)
+	record := logRecords(t, buf)[0]
+	assert.Len(t, record["key_hash"], 16, "Record must carry the short key hash")
+	assert.NotContains(t, record, "key", "Record must not carry the raw key")
+
+	i, buf = newLoggingTest(t, time.Nanosecond, LogKeysFull)
+	i.Set(t.Context(), testKey).SetPrefix(testPrefix).Put(testValue)
+	record = logRecords(t, buf)[0]
+	assert.Equal(t, testPrefix+DefaultKeySeparator+testKey, record["key"], "Record must carry the raw key")
+}
+
+func TestLoggingDecodeFailure(t *testing.T) {
+	i, buf := newLoggingTest(t, 0, LogKeysPrefix)
+	i.Set(t.Context(), testKey).SetPrefix(testPrefix).Put(testValue)
+
+	var out int
+	err := i.Get(t.Context(), testKey, testPrefix).Pull(&out)
+	assert.ErrorIs(t, err, ErrDecode, "Pull must be decode error")
+	_, err = i.GetMany(t.Context(), []string{testKey}, testPrefix).Pull(&map[string]int{})
+	assert.NoError(t, err, "Pull many must be no error")
+
+	records := logRecords(t, buf)
+	if assert.Len(t, records, 2, "Decode failures must be logged") {
+		for _, record := range records {
+			assert.Equal(t, "cache decode failed", record["msg"])
+			assert.Equal(t, "ERROR", record["level"])
+			assert.Equal(t, "decode", record["type"], "Record must carry the error type")
+			assert.Equal(t, testPrefix, record["prefix"], "Record must carry the key prefix")
+		}
+	}
+}
+
+func TestLoggingLifecycle(t *testing.T) {
+	t.Setenv(qore.CONFIG_USED_KEY, "OS")
+	t.Setenv("CACHE_DRIVER", DriverMemory)
+	buf := new(bytes.Buffer)
+	i := New().SetLogger(slog.New(slog.NewTextHandler(buf, nil)))
+	assert.NoError(t, i.Open(), "Open must be no error")
+	assert.NoError(t, i.Close(), "Close must be no error")
+
+	out := buf.String()
+	assert.Contains(t, out, `msg="cache opened"`, "Open must be logged")
+	assert.Contains(t, out, "driver="+DriverMemory, "Open record must carry the driver")
+	assert.Contains(t, out, `msg="cache closed"`, "Close must be logged")
+}
+
+func TestLoggingRedisHook(t *testing.T) {
+	buf := new(bytes.Buffer)
+	hook := &logHook{logger: slog.New(slog.NewTextHandler(buf, nil)), namespace: DefaultNameSpace, failover: true}
+	dialErr := errors.New("connection refused")
+	dial := hook.DialHook(func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if addr == "down:6379" {
+			return nil, dialErr
+		}
+		return nil, nil
+	})
+
+	_, err := dial(t.Context(), "tcp", "down:6379")
+	assert.ErrorIs(t, err, dialErr, "Dial error must be returned")
+	assert.Contains(t, buf.String(), `msg="cache redis dial failed"`, "Dial failure must be logged")
+
+	buf.Reset()
+	dial(t.Context(), "tcp", "master-a:6379")
+	dial(t.Context(), "tcp", "master-a:6379")
+	assert.Empty(t, buf.String(), "Same master must not be logged")
+	dial(t.Context(), "tcp", "master-b:6379")
+	assert.Contains(t, buf.String(), `msg="cache redis master changed" namespace=`+DefaultNameSpace+" from=master-a:6379 to=master-b:6379", "Master change must be logged")
+}
+
+func TestLoggingRedisHookOnce(t *testing.T) {
+	t.Setenv(qore.CONFIG_USED_KEY, "OS")
+	t.Setenv("CACHE_DRIVER", DriverMemory)
+	buf := new(bytes.Buffer)
+	client := redis.NewClient(&redis.Options{
+		Addr:       "down:6379",
+		MaxRetries: -1,
+		Dialer: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return nil, errors.New("connection refused")
+		},
+	})
+	i := New().SetStore(&redisStore{client: client}).SetLogger(slog.New(slog.NewTextHandler(buf, nil)))
+	defer i.Close()
+	i.open()
+	i.open()
+
+	client.Ping(t.Context())
+	assert.Equal(t, 1, strings.Count(buf.String(), `msg="cache redis dial failed"`), "Hook must be installed once")
+}
@@ -0,0 +1,65 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Metrics receives the measurement of every cache operation, plug it through SetMetrics.
+// It is called synchronously after the operation, so it must be fast & safe for concurrent use.
+type Metrics interface {
+	// ObserveOperation records the finished cache operation.
+	ObserveOperation(ctx context.Context, obs Observation)
+}
+
+// Observation defines the measurement of a single cache operation.
+type Observation struct {
+	// Namespace is the config Namespace of the instance.
+	Namespace string
+
+	// Op is the operation, one of OpGet, OpSet, OpDel, OpHas, OpScan, OpRateLimit,
+	// OpAcquire, OpExtend or OpRelease.
+	Op string
+
+	// Prefix is the per call key prefix, if any.
+	Prefix string
+
+	// Hits & Misses are the number of keys found & not found by OpGet & OpHas.
+	Hits   int
+	Misses int
+
+	// Size is the number of the value bytes read or written.
+	Size int
+
+	// Duration is how long the operation took.
+	Duration time.Duration
+
+	// Err is the operation error, the cache miss is not an error.
+	Err error
+}
+
+// ErrorType returns the low cardinality type of the operation error: "timeout", "backend_unavailable",
+// "decode", "decrypt", "invalid" or "other", empty for nil error.
+//
+//	if obs.Err != nil {
+//		errorsTotal.WithLabelValues(cache.ErrorType(obs.Err)).Inc()
+//	}
+func ErrorType(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, ErrTimeout), errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, ErrBackendUnavailable):
+		return "backend_unavailable"
+	case errors.Is(err, ErrDecode):
+		return "decode"
+	case errors.Is(err, ErrDecrypt):
+		return "decrypt"
+	case errors.Is(err, ErrEmptyKey), errors.Is(err, ErrEmptyPrefix), errors.Is(err, ErrOutNonPointer):
+		return "invalid"
+	default:
+		return "other"
+	}
+}
@@ -0,0 +1,198 @@
+package cache
+
+import (
+	"context"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+)
+
+// PrometheusOptions defines the Prometheus metrics of the cache operations.
+type PrometheusOptions struct {
+	// PrefixLabel fills the "prefix" label with the per call key prefix, otherwise it is empty.
+	// Keep it off when the prefixes are unbounded.
+	PrefixLabel bool
+
+	// DurationBuckets defines the latency histogram buckets in seconds. Default is prometheus.DefBuckets.
+	DurationBuckets []float64
+
+	// SizeBuckets defines the payload size histogram buckets in bytes. Default is 64B up to 1MiB.
+	SizeBuckets []float64
+}
+
+// PrometheusMetrics is the Metrics hook & the prometheus.Collector of the cache operations,
+// it also exports the go-redis pool stats of the instances using it.
+type PrometheusMetrics struct {
+	prefixLabel bool
+	operations  *prometheus.CounterVec
+	hits        *prometheus.CounterVec
+	misses      *prometheus.CounterVec
+	errors      *prometheus.CounterVec
+	duration    *prometheus.HistogramVec
+	size        *prometheus.HistogramVec
+
+	// Pool stats.
+	mu             sync.Mutex
+	pools          map[string]*Instance
+	poolHits       *prometheus.Desc
+	poolMisses     *prometheus.Desc
+	poolTimeouts   *prometheus.Desc
+	poolWaits      *prometheus.Desc
+	poolWaitTime   *prometheus.Desc
+	poolTotalConns *prometheus.Desc
+	poolIdleConns  *prometheus.Desc
+	poolStaleConns *prometheus.Desc
+}
+
+// poolObserver is implemented by the metrics hook exporting the go-redis pool stats.
+type poolObserver interface {
+	observePool(i *Instance)
+	forgetPool(i *Instance)
+}
+
+// NewPrometheusMetrics creates the Prometheus metrics, register it to the Prometheus registry
+// & plug it into the instances through SetMetrics.
+//
+//	metrics := cache.NewPrometheusMetrics(cache.PrometheusOptions{PrefixLabel: true})
+//	prometheus.MustRegister(metrics)
+func NewPrometheusMetrics(opts PrometheusOptions) *PrometheusMetrics {
+	if len(opts.DurationBuckets) == 0 {
+		opts.DurationBuckets = prometheus.DefBuckets
+	}
+	if len(opts.SizeBuckets) == 0 {
+		opts.SizeBuckets = prometheus.ExponentialBuckets(64, 4, 10)
+	}
+	labels := []string{"namespace", "prefix", "op"}
+	poolLabels := []string{"namespace"}
+	return &PrometheusMetrics{
+		prefixLabel: opts.PrefixLabel,
+		operations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cache_operations_total",
+			Help: "Total number of the cache operations.",
+		}, labels),
+		hits: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cache_hits_total",
+			Help: "Total number of the keys found by the cache reads.",
+		}, labels),
+		misses: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cache_misses_total",
+			Help: "Total number of the keys not found by the cache reads.",
+		}, labels),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cache_errors_total",
+			Help: "Total number of the failed cache operations by the error type.",
+		}, append(labels, "type")),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "cache_operation_duration_seconds",
+			Help:    "Latency of the cache operations.",
+			Buckets: opts.DurationBuckets,
+		}, labels),
+		size: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "cache_payload_size_bytes",
+			Help:    "Size of the values read or written by the cache operations.",
+			Buckets: opts.SizeBuckets,
+		}, labels),
+		pools:          make(map[string]*Instance),
+		poolHits:       prometheus.NewDesc("cache_pool_hits_total", "Number of times a free connection was found in the redis pool.", poolLabels, nil),
+		poolMisses:     prometheus.NewDesc("cache_pool_misses_total", "Number of times a free connection was not found in the redis pool.", poolLabels, nil),
+		poolTimeouts:   prometheus.NewDesc("cache_pool_timeouts_total", "Number of times a wait for the redis pool connection timed out.", poolLabels, nil),
+		poolWaits:      prometheus.NewDesc("cache_pool_waits_total", "Number of times a redis pool connection was waited.", poolLabels, nil),
+		poolWaitTime:   prometheus.NewDesc("cache_pool_wait_seconds_total", "Total time spent waiting for a redis pool connection.", poolLabels, nil),
+		poolTotalConns: prometheus.NewDesc("cache_pool_connections", "Number of the connections in the redis pool.", poolLabels, nil),
+		poolIdleConns:  prometheus.NewDesc("cache_pool_idle_connections", "Number of the idle connections in the redis pool.", poolLabels, nil),
+		poolStaleConns: prometheus.NewDesc("cache_pool_stale_connections_total", "Number of the stale connections removed from the redis pool.", poolLabels, nil),
+	}
+}
+
+// ObserveOperation records the finished cache operation.
+func (m *PrometheusMetrics) ObserveOperation(_ context.Context, obs Observation) {
+	prefix := ""
+	if m.prefixLabel {
+		prefix = obs.Prefix
+	}
+	labels := prometheus.Labels{"namespace": obs.Namespace, "prefix": prefix, "op": obs.Op}
+	m.operations.With(labels).Inc()
+	m.duration.With(labels).Observe(obs.Duration.Seconds())
+	if obs.Hits > 0 {
+		m.hits.With(labels).Add(float64(obs.Hits))
+	}
+	if obs.Misses > 0 {
+		m.misses.With(labels).Add(float64(obs.Misses))
+	}
+	if obs.Size > 0 {
+		m.size.With(labels).Observe(float64(obs.Size))
+	}
+	if obs.Err != nil {
+		m.errors.WithLabelValues(obs.Namespace, prefix, obs.Op, ErrorType(obs.Err)).Inc()
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (m *PrometheusMetrics) Describe(ch chan<- *prometheus.Desc) {
+	m.operations.Describe(ch)
+	m.hits.Describe(ch)
+	m.misses.Describe(ch)
+	m.errors.Describe(ch)
+	m.duration.Describe(ch)
+	m.size.Describe(ch)
+	for _, desc := range []*prometheus.Desc{
+		m.poolHits, m.poolMisses, m.poolTimeouts, m.poolWaits,
+		m.poolWaitTime, m.poolTotalConns, m.poolIdleConns, m.poolStaleConns,
+	} {
+		ch <- desc
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (m *PrometheusMetrics) Collect(ch chan<- prometheus.Metric) {
+	m.operations.Collect(ch)
+	m.hits.Collect(ch)
+	m.misses.Collect(ch)
+	m.errors.Collect(ch)
+	m.duration.Collect(ch)
+	m.size.Collect(ch)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for namespace, i := range m.pools {
+		client, err := i.Client()
+		if err != nil {
+			continue
+		}
+		m.collectPool(ch, namespace, client.PoolStats())
+	}
+}
+
+// collectPool sends the go-redis pool stats of the namespace.
+func (m *PrometheusMetrics) collectPool(ch chan<- prometheus.Metric, namespace string, stats *redis.PoolStats) {
+	if stats == nil {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(m.poolHits, prometheus.CounterValue, float64(stats.Hits), namespace)
+	ch <- prometheus.MustNewConstMetric(m.poolMisses, prometheus.CounterValue, float64(stats.Misses), namespace)
+	ch <- prometheus.MustNewConstMetric(m.poolTimeouts, prometheus.CounterValue, float64(stats.Timeouts), namespace)
+	ch <- prometheus.MustNewConstMetric(m.poolWaits, prometheus.CounterValue, float64(stats.WaitCount), namespace)
+	ch <- prometheus.MustNewConstMetric(m.poolWaitTime, prometheus.CounterValue, float64(stats.WaitDurationNs)/1e9, namespace)
+	ch <- prometheus.MustNewConstMetric(m.poolTotalConns, prometheus.GaugeValue, float64(stats.TotalConns), namespace)
+	ch <- prometheus.MustNewConstMetric(m.poolIdleConns, prometheus.GaugeValue, float64(stats.IdleConns), namespace)
+	ch <- prometheus.MustNewConstMetric(m.poolStaleConns, prometheus.CounterValue, float64(stats.StaleConns), namespace)
+}
+
+// observePool exports the go-redis pool stats of the instance, the instances sharing the namespace
+// are exported once.
+func (m *PrometheusMetrics) observePool(i *Instance) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pools[i.cfg.Namespace] = i
+}
+
+// forgetPool stops exporting the go-redis pool stats of the closed instance, unless the namespace is
+// already exported by another instance.
+func (m *PrometheusMetrics) forgetPool(i *Instance) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.pools[i.cfg.Namespace] == i {
+		delete(m.pools, i.cfg.Namespace)
+	}
+}
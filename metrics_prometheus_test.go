@@ -0,0 +1,109 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/qoinlyid/qore"
+	"github.com/stretchr/testify/assert"
+)
+
+// gatherPrometheus returns the gathered metric families by name.
+func gatherPrometheus(t *testing.T, metrics *PrometheusMetrics) map[string]*dto.MetricFamily {
+	t.Helper()
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(metrics)
+	families, err := registry.Gather()
+	assert.NoError(t, err, "Gather must be no error")
+	byName := make(map[string]*dto.MetricFamily, len(families))
+	for _, family := range families {
+		byName[family.GetName()] = family
+	}
+	return byName
+}
+
+// labelValue returns the value of the metric label.
+func labelValue(metric *dto.Metric, name string) string {
+	for _, label := range metric.GetLabel() {
+		if label.GetName() == name {
+			return label.GetValue()
+		}
+	}
+	return ""
+}
+
+func TestPrometheusMetrics(t *testing.T) {
+	metrics := NewPrometheusMetrics(PrometheusOptions{PrefixLabel: true})
+	i := newMemoryTest(t).SetMetrics(metrics)
+	_, err := i.Set(t.Context(), testKey).SetPrefix(testPrefix).Put(testValue)
+	assert.NoError(t, err, "Put must be no error")
+	var out string
+	i.Get(t.Context(), testKey, testPrefix).Pull(&out)
+	i.Get(t.Context(), "missing", testPrefix).Pull(&out)
+
+	families := gatherPrometheus(t, metrics)
+	ops := families["cache_operations_total"]
+	assert.NotNil(t, ops, "Operations must be exported")
+	for _, metric := range ops.GetMetric() {
+		assert.Equal(t, i.cfg.Namespace, labelValue(metric, "namespace"), "Namespace label must be set")
+		assert.Equal(t, testPrefix, labelValue(metric, "prefix"), "Prefix label must be set")
+		switch labelValue(metric, "op") {
+		case OpGet:
+			assert.Equal(t, float64(2), metric.GetCounter().GetValue(), "Get must be counted")
+		case OpSet:
+			assert.Equal(t, float64(1), metric.GetCounter().GetValue(), "Set must be counted")
+		}
+	}
+	assert.Equal(t, float64(1), families["cache_hits_total"].GetMetric()[0].GetCounter().GetValue(), "Hit must be counted")
+	assert.Equal(t, float64(1), families["cache_misses_total"].GetMetric()[0].GetCounter().GetValue(), "Miss must be counted")
+	assert.NotNil(t, families["cache_operation_duration_seconds"], "Latency must be exported")
+	assert.NotNil(t, families["cache_payload_size_bytes"], "Payload size must be exported")
+	assert.Nil(t, families["cache_errors_total"], "Miss must not be counted as error")
+	assert.Nil(t, families["cache_pool_connections"], "Memory driver must not export pool stats")
+}
+
+func TestPrometheusMetricsWithoutPrefixLabel(t *testing.T) {
+	metrics := NewPrometheusMetrics(PrometheusOptions{})
+	i := newMemoryTest(t).SetMetrics(metrics)
+	i.Set(t.Context(), testKey).SetPrefix(testPrefix).Put(testValue)
+
+	families := gatherPrometheus(t, metrics)
+	metric := families["cache_operations_total"].GetMetric()[0]
+	assert.Equal(t, "", labelValue(metric, "prefix"), "Prefix label must be empty")
+}
+
+func TestPrometheusMetricsPoolStats(t *testing.T) {
+	t.Setenv(qore.CONFIG_USED_KEY, "./.env")
+	metrics := NewPrometheusMetrics(PrometheusOptions{})
+	i := New().SetMetrics(metrics)
+	if err := i.Open(); err != nil {
+		t.Skipf("Redis is not available: %s", err)
+	}
+	defer i.Close()
+	if _, err := i.store.Ping(t.Context()); err != nil {
+		t.Skipf("Redis is not available: %s", err)
+	}
+	_, err := i.Set(t.Context(), "TestPrometheusMetrics").SetTTL(time.Second).Put(testValue)
+	assert.NoError(t, err, "Put must be no error")
+
+	families := gatherPrometheus(t, metrics)
+	conns := families["cache_pool_connections"]
+	if assert.NotEmpty(t, conns.GetMetric(), "Pool stats must be exported") {
+		assert.Equal(t, i.cfg.Namespace, labelValue(conns.GetMetric()[0], "namespace"), "Namespace label must be set")
+		assert.Greater(t, conns.GetMetric()[0].GetGauge().GetValue(), float64(0), "Pool must have connection")
+	}
+}
+
+func TestPrometheusMetricsPoolClose(t *testing.T) {
+	metrics := NewPrometheusMetrics(PrometheusOptions{})
+	i := newMemoryTest(t).SetMetrics(metrics)
+	other := New().SetMetrics(metrics)
+	assert.Len(t, metrics.pools, 1, "Instances sharing the namespace must be exported once")
+
+	i.Close()
+	assert.Len(t, metrics.pools, 1, "Closing the replaced instance must keep the exported one")
+	other.Close()
+	assert.Empty(t, metrics.pools, "Closed instance must not be exported")
+}
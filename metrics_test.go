@@ -0,0 +1,105 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordMetrics is a Metrics hook recording the observations.
+type recordMetrics struct {
+	mu  sync.Mutex
+	obs []Observation
+}
+
+func (m *recordMetrics) ObserveOperation(_ context.Context, obs Observation) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.obs = append(m.obs, obs)
+}
+
+func (m *recordMetrics) last() Observation {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.obs[len(m.obs)-1]
+}
+
+func TestMetricsOperations(t *testing.T) {
+	metrics := &recordMetrics{}
+	i := newMemoryTest(t).SetMetrics(metrics)
+	ctx := t.Context()
+
+	_, err := i.Set(ctx, testKey).SetPrefix(testPrefix).SetTTL(time.Minute).Put(testValue)
+	assert.NoError(t, err, "Put must be no error")
+	obs := metrics.last()
+	assert.Equal(t, OpSet, obs.Op, "Put must be observed as set")
+	assert.Equal(t, testPrefix, obs.Prefix, "Prefix must be observed")
+	assert.Equal(t, i.cfg.Namespace, obs.Namespace, "Namespace must be observed")
+	assert.Equal(t, len(testValue), obs.Size, "Written size must be observed")
+
+	var out string
+	assert.NoError(t, i.Get(ctx, testKey, testPrefix).Pull(&out), "Pull must be no error")
+	obs = metrics.last()
+	assert.Equal(t, OpGet, obs.Op, "Pull must be observed as get")
+	assert.Equal(t, 1, obs.Hits, "Hit must be observed")
+	assert.Equal(t, len(testValue), obs.Size, "Read size must be observed")
+
+	err = i.Get(ctx, "missing").Pull(&out)
+	assert.ErrorIs(t, err, ErrCacheMiss, "Pull must be miss")
+	obs = metrics.last()
+	assert.Equal(t, 1, obs.Misses, "Miss must be observed")
+	assert.NoError(t, obs.Err, "Miss must not be observed as error")
+
+	i.Has(ctx, testKey, testPrefix)
+	assert.Equal(t, Observation{Namespace: i.cfg.Namespace, Op: OpHas, Prefix: testPrefix, Hits: 1, Duration: metrics.last().Duration}, metrics.last(), "Has must be observed")
+
+	_, err = i.GetAllKeys(ctx, testPrefix)
+	assert.NoError(t, err, "GetAllKeys must be no error")
+	assert.Equal(t, OpScan, metrics.last().Op, "GetAllKeys must be observed as scan")
+
+	_, err = i.Delete(ctx, testKey, testPrefix).Perform()
+	assert.NoError(t, err, "Perform must be no error")
+	assert.Equal(t, OpDel, metrics.last().Op, "Perform must be observed as del")
+
+	_, err = i.Set(ctx, testKey).RateLimitOnce(time.Minute)
+	assert.NoError(t, err, "RateLimitOnce must be no error")
+	assert.Equal(t, OpRateLimit, metrics.last().Op, "RateLimitOnce must be observed as ratelimit")
+	assert.Equal(t, KeyRateLimit, metrics.last().Prefix, "Rate limit prefix must be observed")
+}
+
+func TestMetricsMany(t *testing.T) {
+	metrics := &recordMetrics{}
+	i := newMemoryTest(t).SetMetrics(metrics)
+	_, err := i.SetMany(t.Context(), testPrefix).PutMany(Item{Key: "1", Value: testValue}, Item{Key: "2", Value: testValue})
+	assert.NoError(t, err, "PutMany must be no error")
+	assert.Equal(t, Observation{Namespace: i.cfg.Namespace, Op: OpSet, Prefix: testPrefix, Size: 2 * len(testValue), Duration: metrics.last().Duration}, metrics.last(), "PutMany must be observed")
+
+	vals := map[string]string{}
+	_, err = i.GetMany(t.Context(), []string{"1", "2", "3"}, testPrefix).Pull(&vals)
+	assert.NoError(t, err, "GetMany must be no error")
+	obs := metrics.last()
+	assert.Equal(t, 2, obs.Hits, "Hits must be observed")
+	assert.Equal(t, 1, obs.Misses, "Misses must be observed")
+}
+
+func TestMetricsError(t *testing.T) {
+	metrics := &recordMetrics{}
+	i := newMemoryTest(t).SetMetrics(metrics)
+	i.store = downStore{i.store}
+	_, err := i.HasE(t.Context(), testKey)
+	assert.Error(t, err, "HasE must be error")
+	assert.Equal(t, "backend_unavailable", ErrorType(metrics.last().Err), "Error type must be observed")
+}
+
+func TestErrorType(t *testing.T) {
+	assert.Equal(t, "", ErrorType(nil), "Nil error must have no type")
+	assert.Equal(t, "timeout", ErrorType(&OpError{Kind: ErrTimeout}), "Timeout must be typed")
+	assert.Equal(t, "decode", ErrorType(&OpError{Kind: ErrDecode}), "Decode must be typed")
+	assert.Equal(t, "decrypt", ErrorType(&OpError{Kind: ErrDecrypt}), "Decrypt must be typed")
+	assert.Equal(t, "invalid", ErrorType(ErrEmptyKey), "Empty key must be typed")
+	assert.Equal(t, "other", ErrorType(errors.New("boom")), "Unknown error must be typed")
+}
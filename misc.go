@@ -6,39 +6,61 @@ import (
 	"time"
 
 	"github.com/qoinlyid/qore"
-	"github.com/redis/go-redis/v9"
 )
 
 // Has checks whether the specified key exists in cache.
 // An optional prefix can be provided, which is prepended to the key along with
 // the default key separator.
 // If the context is nil, a 1-second timeout context is used.
-// Returns true if the key exists, false otherwise.
+// Returns true if the key exists, false otherwise or when the check fails, see HasE.
 //
 //	exists := cache.Has(ctx, "myKey")
 func (i *Instance) Has(ctx context.Context, key string, prefix ...string) bool {
+	exists, _ := i.HasE(ctx, key, prefix...)
+	return exists
+}
+
+// HasE is like Has but also returns the error, so the absent key can be told apart from
+// the unavailable backend.
+//
+//	exists, err := cache.HasE(ctx, "myKey")
+//	if errors.Is(err, cache.ErrBackendUnavailable) {
+//		log.Println(err)
+//	}
+func (i *Instance) HasE(ctx context.Context, key string, prefix ...string) (bool, error) {
 	// Validate.
-	if err := i.validateClient(); err != nil {
-		return false
+	if err := i.validateStore(); err != nil {
+		return false, err
 	}
 	if ctx == nil {
 		c, cancel := context.WithTimeout(context.Background(), time.Second)
 		defer cancel()
 		ctx = c
 	}
+	op := &operation{name: OpHas, key: key}
 	if len(prefix) > 0 {
 		if !qore.ValidationIsEmpty(prefix[0]) {
 			key = prefix[0] + DefaultKeySeparator + key
+			op.key, op.prefix = key, prefix[0]
 		}
 	}
-	key = i.cfg.Namespace + DefaultKeySeparator + key
 
 	// Exec.
-	res, err := i.client.Exists(ctx, key).Result()
-	if err != nil {
-		return false
-	}
-	return res > 0
+	var exists bool
+	err := i.instrument(ctx, op, func(ctx context.Context) error {
+		res, err := i.store.Exists(ctx, i.cfg.Namespace+DefaultKeySeparator+key)
+		if err != nil {
+			return opError("has", key, err)
+		}
+		exists = res > 0
+		if exists {
+			op.hits = 1
+		} else {
+			op.misses = 1
+		}
+		return nil
+	})
+	return exists, err
 }
 
 type Keyer struct {
@@ -47,7 +69,7 @@ type Keyer struct {
 	Key       string
 }
 
-// GetAllKeys retrieves all Redis keys that match the specified prefix.
+// GetAllKeys retrieves all cache keys that match the specified prefix.
 // The prefix is normalized to ensure it ends with the default key separator
 // before performing a SCAN operation.
 //
@@ -61,7 +83,7 @@ type Keyer struct {
 //	}
 func (i *Instance) GetAllKeys(ctx context.Context, prefix string) (keys []Keyer, err error) {
 	// Validate.
-	if e := i.validateClient(); e != nil {
+	if e := i.validateStore(); e != nil {
 		err = e
 		return
 	}
@@ -76,27 +98,18 @@ func (i *Instance) GetAllKeys(ctx context.Context, prefix string) (keys []Keyer,
 	}
 	match := i.cfg.Namespace + DefaultKeySeparator + strings.TrimSuffix(prefix, "*") + DefaultKeySeparator + "*"
 
-	// Perform SCAN in the each cluster node.
-	if i.clustering {
-		clusterClient, ok := i.client.(*redis.ClusterClient)
-		if !ok {
-			err = ErrClientNotCluster
-			return
-		}
-		clusterClient.ForEachMaster(ctx, func(ctx context.Context, client *redis.Client) error {
-			return scan(client, ctx, match, &keys)
-		})
-	}
-
 	// Perform SCAN.
-	err = scan(i.client, ctx, match, &keys)
-	return
-}
-
-func scan(rdb redis.UniversalClient, ctx context.Context, match string, founds *[]Keyer) error {
-	iter := rdb.Scan(ctx, 0, match, 0).Iterator()
-	for iter.Next(ctx) {
-		vals := strings.Split(iter.Val(), DefaultKeySeparator)
+	var founds []string
+	err = i.instrument(ctx, &operation{name: OpScan, prefix: prefix}, func(ctx context.Context) error {
+		var e error
+		founds, e = i.store.Scan(ctx, match)
+		return opError("keys", prefix, e)
+	})
+	if err != nil {
+		return
+	}
+	for _, found := range founds {
+		vals := strings.Split(found, DefaultKeySeparator)
 		reverseStrings(vals)
 		keyer := Keyer{}
 		for i, v := range vals {
@@ -109,10 +122,7 @@ func scan(rdb redis.UniversalClient, ctx context.Context, match string, founds *
 				keyer.Namespace = v
 			}
 		}
-		*founds = append(*founds, keyer)
+		keys = append(keys, keyer)
 	}
-	if iter.Err() != nil {
-		return iter.Err()
-	}
-	return nil
+	return
 }
@@ -0,0 +1,192 @@
+package cache
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/qoinlyid/qore"
+)
+
+// RateLimitResult defines the outcome of the rate limiter call.
+type RateLimitResult struct {
+	// Allowed tells whether the call is permitted.
+	Allowed bool
+
+	// Limit is the max calls of the period.
+	Limit int
+
+	// Remaining is the calls left before the limit is reached.
+	Remaining int
+
+	// RetryAfter is the time until the next call is permitted, zero when the call is allowed.
+	RetryAfter time.Duration
+
+	// ResetAfter is the time until the limiter is back to its full capacity.
+	ResetAfter time.Duration
+}
+
+// RateLimitWindow permits up to limit calls within any sliding window of the given period, using the sliding
+// window counter of the current & the previous fixed windows. The windows follow the store clock (redis TIME),
+// not the instance clock. Both window counters are kept in the same hash slot, so it also works in cluster
+// mode. The store must implement RateLimiter, otherwise ErrRateLimitNotSupported is returned.
+//
+//	res, err := s.RateLimitWindow(100, time.Minute)
+//	if err != nil {
+//		log.Println(err)
+//	}
+//	if !res.Allowed {
+//		log.Println("blocked, retry after", res.RetryAfter)
+//	}
+func (s *setter) RateLimitWindow(limit int, window time.Duration) (RateLimitResult, error) {
+	if !qore.ValidationIsEmpty(s.key) {
+		s = s.SetPrefix(KeyRateLimit)
+	}
+	return s.rateLimitWindowFn(s, limit, window)
+}
+
+// RateLimitGCRA permits rate calls per period with up to burst calls at once, using the generic cell rate
+// algorithm (GCRA): the calls are spread evenly over the period & the unused capacity accumulates up to burst.
+// Every call consumes cost units, so the weighted calls are limited by the same key, cost must not exceed
+// burst. The time is taken from the store clock (redis TIME), not the instance clock. The store must implement
+// RateLimiter, otherwise ErrRateLimitNotSupported is returned.
+//
+//	res, err := s.RateLimitGCRA(100, time.Minute, 20, 1)
+//	if err != nil {
+//		log.Println(err)
+//	}
+//	if !res.Allowed {
+//		log.Println("blocked, retry after", res.RetryAfter)
+//	}
+func (s *setter) RateLimitGCRA(rate int, period time.Duration, burst, cost int) (RateLimitResult, error) {
+	if !qore.ValidationIsEmpty(s.key) {
+		s = s.SetPrefix(KeyRateLimit)
+	}
+	return s.rateLimitGCRAFn(s, rate, period, burst, cost)
+}
+
+// rateLimitKey returns the namespaced rate limit key with its hash tag, so all keys derived from it
+// are in the same hash slot.
+func (i *Instance) rateLimitKey(key string) string {
+	return i.cfg.Namespace + DefaultKeySeparator + "{" + key + "}"
+}
+
+// rateLimiter returns the RateLimiter of the backend store.
+func (i *Instance) rateLimiter() (RateLimiter, error) {
+	if err := i.validateStore(); err != nil {
+		return nil, err
+	}
+	limiter, ok := i.store.(RateLimiter)
+	if !ok {
+		return nil, ErrRateLimitNotSupported
+	}
+	return limiter, nil
+}
+
+// rateLimitWindow helper to run the sliding window counter of the rate limit key.
+func (i *Instance) rateLimitWindow(set *setter, limit int, window time.Duration) (res RateLimitResult, err error) {
+	defer set.cleanup()
+	op := &operation{name: OpRateLimit, key: set.key, prefix: set.prefix, ttl: window}
+	err = i.instrument(set.ctx, op, func(ctx context.Context) error {
+		// Validate.
+		limiter, err := i.rateLimiter()
+		if err != nil {
+			return err
+		}
+		if qore.ValidationIsEmpty(set.key) {
+			return ErrEmptyKey
+		}
+		if limit <= 0 || window <= 0 {
+			return ErrRateLimitInvalid
+		}
+
+		// The windows are taken from the store clock, so the clocks of the instances do not matter.
+		allowed, cur, prev, elapsed, err := limiter.SlidingWindow(ctx, i.rateLimitKey(set.key), int64(limit), window)
+		if err != nil {
+			return opError("ratelimit", set.key, err)
+		}
+		res = slidingWindowResult(limit, window, elapsed, allowed, cur, prev)
+		return nil
+	})
+	return
+}
+
+// rateLimitGCRA helper to run the generic cell rate algorithm of the rate limit key.
+func (i *Instance) rateLimitGCRA(
+	set *setter, rate int, period time.Duration, burst, cost int,
+) (res RateLimitResult, err error) {
+	defer set.cleanup()
+	op := &operation{name: OpRateLimit, key: set.key, prefix: set.prefix, ttl: period}
+	err = i.instrument(set.ctx, op, func(ctx context.Context) error {
+		// Validate.
+		limiter, err := i.rateLimiter()
+		if err != nil {
+			return err
+		}
+		if qore.ValidationIsEmpty(set.key) {
+			return ErrEmptyKey
+		}
+		if rate <= 0 || period <= 0 || burst <= 0 || cost <= 0 || cost > burst {
+			return ErrRateLimitInvalid
+		}
+
+		// Every unit moves the theoretical arrival time by the emission interval, the burst is tolerated
+		// ahead of now. Now is taken from the store clock, so the clocks of the instances do not matter.
+		emission := max(period.Microseconds()/int64(rate), 1)
+		tolerance := emission * int64(burst)
+		increment := emission * int64(cost)
+		allowed, tat, now, err := limiter.GCRA(ctx, i.rateLimitKey(set.key), increment, tolerance)
+		if err != nil {
+			return opError("ratelimit", set.key, err)
+		}
+		res = gcraResult(burst, emission, tolerance, increment, now, allowed, tat)
+		return nil
+	})
+	return
+}
+
+// gcraResult computes the result from the theoretical arrival time after the call, the times are microseconds.
+func gcraResult(burst int, emission, tolerance, increment, now int64, allowed bool, tat int64) RateLimitResult {
+	res := RateLimitResult{
+		Allowed:    allowed,
+		Limit:      burst,
+		Remaining:  int(max(0, now-(tat-tolerance)) / emission),
+		ResetAfter: time.Duration(max(0, tat-now)) * time.Microsecond,
+	}
+	if !allowed {
+		res.RetryAfter = time.Duration(tat+increment-tolerance-now) * time.Microsecond
+	}
+	return res
+}
+
+// slidingWindowResult computes the result from the window counters, elapsed is the time passed since
+// the current window started.
+func slidingWindowResult(limit int, window, elapsed time.Duration, allowed bool, cur, prev int64) RateLimitResult {
+	left := window - elapsed
+	count := float64(prev)*float64(left)/float64(window) + float64(cur)
+	res := RateLimitResult{
+		Allowed:   allowed,
+		Limit:     limit,
+		Remaining: max(0, limit-int(math.Ceil(count))),
+	}
+	switch {
+	case cur > 0:
+		res.ResetAfter = left + window
+	case prev > 0:
+		res.ResetAfter = left
+	}
+	if allowed {
+		return res
+	}
+
+	// Wait until the weighted count leaves room for one more call, either while the previous window
+	// slides out or, when the current window alone is full, once the current window becomes the previous.
+	room := float64(limit - 1)
+	if float64(cur) <= room && prev > 0 {
+		res.RetryAfter = left - time.Duration((room-float64(cur))/float64(prev)*float64(window))
+	} else {
+		res.RetryAfter = left + window - time.Duration(room/float64(cur)*float64(window))
+	}
+	res.RetryAfter = max(res.RetryAfter, time.Millisecond)
+	return res
+}
@@ -0,0 +1,185 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// RateLimitKeyFunc derives the rate limit key of the request, the empty key skips the limit.
+type RateLimitKeyFunc func(c echo.Context) (string, error)
+
+// RateLimitFunc applies the rate limiter to the key.
+type RateLimitFunc func(ctx context.Context, key string) (RateLimitResult, error)
+
+// RateLimitConfig defines the echo rate limit middleware.
+type RateLimitConfig struct {
+	// Skipper skips the middleware when it returns true, e.g. middleware.DefaultSkipper.
+	Skipper func(c echo.Context) bool
+
+	// KeyFunc derives the rate limit key of the request. Default is RateLimitKeyIP.
+	KeyFunc RateLimitKeyFunc
+
+	// Limiter applies the rate limiter to the key, e.g. WindowLimiter or GCRALimiter. Required.
+	Limiter RateLimitFunc
+
+	// Prefix is prepended to the key, so the middlewares with different limits do not share the key.
+	Prefix string
+
+	// DenyHandler responds to the rate limited request. Default returns 429 Too Many Requests.
+	DenyHandler func(c echo.Context, res RateLimitResult) error
+
+	// ErrorHandler handles the limiter error. Default lets the request through, so the cache outage
+	// does not take the endpoint down.
+	ErrorHandler func(c echo.Context, err error) error
+}
+
+// WindowLimiter returns the RateLimitFunc permitting up to limit calls within any sliding window, see
+// RateLimitWindow.
+//
+//	limiter := cache.WindowLimiter(100, time.Minute)
+func (i *Instance) WindowLimiter(limit int, window time.Duration) RateLimitFunc {
+	return func(ctx context.Context, key string) (RateLimitResult, error) {
+		return i.Set(ctx, key).RateLimitWindow(limit, window)
+	}
+}
+
+// GCRALimiter returns the RateLimitFunc permitting rate calls per period with up to burst calls at once,
+// every call consumes cost units, see RateLimitGCRA.
+//
+//	limiter := cache.GCRALimiter(100, time.Minute, 20, 1)
+func (i *Instance) GCRALimiter(rate int, period time.Duration, burst, cost int) RateLimitFunc {
+	return func(ctx context.Context, key string) (RateLimitResult, error) {
+		return i.Set(ctx, key).RateLimitGCRA(rate, period, burst, cost)
+	}
+}
+
+// RateLimitMiddleware returns the echo middleware applying the configured limiter to the key of every request.
+// It sets the RateLimit-Limit, RateLimit-Remaining & RateLimit-Reset headers, plus Retry-After on the
+// rate limited request that is rejected with 429 Too Many Requests.
+//
+//	c := cache.New()
+//	e.Use(cache.RateLimitMiddleware(cache.RateLimitConfig{
+//		KeyFunc: cache.RateLimitKeys(cache.RateLimitKeyRoute(), cache.RateLimitKeyIP()),
+//		Limiter: c.WindowLimiter(100, time.Minute),
+//	}))
+func RateLimitMiddleware(cfg RateLimitConfig) echo.MiddlewareFunc {
+	if cfg.Limiter == nil {
+		panic("cache: rate limit middleware requires a limiter")
+	}
+	if cfg.KeyFunc == nil {
+		cfg.KeyFunc = RateLimitKeyIP()
+	}
+	if cfg.DenyHandler == nil {
+		cfg.DenyHandler = func(c echo.Context, res RateLimitResult) error {
+			return echo.NewHTTPError(http.StatusTooManyRequests)
+		}
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if cfg.Skipper != nil && cfg.Skipper(c) {
+				return next(c)
+			}
+			key, err := cfg.KeyFunc(c)
+			if err != nil {
+				return err
+			}
+			if key == "" {
+				return next(c)
+			}
+			if cfg.Prefix != "" {
+				key = cfg.Prefix + DefaultKeySeparator + key
+			}
+
+			res, err := cfg.Limiter(c.Request().Context(), key)
+			if err != nil {
+				if cfg.ErrorHandler != nil {
+					return cfg.ErrorHandler(c, err)
+				}
+				return next(c)
+			}
+			header := c.Response().Header()
+			header.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+			header.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			header.Set("RateLimit-Reset", headerSeconds(res.ResetAfter))
+			if !res.Allowed {
+				header.Set(echo.HeaderRetryAfter, headerSeconds(res.RetryAfter))
+				return cfg.DenyHandler(c, res)
+			}
+			return next(c)
+		}
+	}
+}
+
+// RateLimitKeyIP derives the key from the client IP. Without the echo IPExtractor it is the remote address
+// of the connection, the X-Forwarded-For & X-Real-IP headers are ignored since any client can spoof them.
+// Behind a proxy set the IPExtractor trusting only the proxy, e.g. echo.ExtractIPFromXFFHeader.
+//
+//	e.IPExtractor = echo.ExtractIPFromXFFHeader(echo.TrustIPRange(proxyNet))
+func RateLimitKeyIP() RateLimitKeyFunc {
+	return func(c echo.Context) (string, error) {
+		if c.Echo().IPExtractor != nil {
+			return c.RealIP(), nil
+		}
+		return echo.ExtractIPDirect()(c.Request()), nil
+	}
+}
+
+// RateLimitKeyHeader derives the key from the request header, e.g. the API key.
+// The request without the header is rejected with 400 Bad Request.
+func RateLimitKeyHeader(name string) RateLimitKeyFunc {
+	return func(c echo.Context) (string, error) {
+		val := c.Request().Header.Get(name)
+		if val == "" {
+			return "", echo.NewHTTPError(http.StatusBadRequest, "missing header "+name)
+		}
+		return val, nil
+	}
+}
+
+// RateLimitKeyUser derives the key from the user stored in the echo context under the given key,
+// e.g. by the auth middleware. The request without the user is rejected with 401 Unauthorized.
+func RateLimitKeyUser(contextKey string) RateLimitKeyFunc {
+	return func(c echo.Context) (string, error) {
+		user := c.Get(contextKey)
+		if user == nil {
+			return "", echo.ErrUnauthorized
+		}
+		return fmt.Sprint(user), nil
+	}
+}
+
+// RateLimitKeyRoute derives the key from the request method & the route path, e.g. "GET /users/:id".
+func RateLimitKeyRoute() RateLimitKeyFunc {
+	return func(c echo.Context) (string, error) {
+		return c.Request().Method + " " + c.Path(), nil
+	}
+}
+
+// RateLimitKeys joins the keys of the key functions, e.g. the route & the IP to limit every client per route.
+// The empty key of any function skips the limit.
+func RateLimitKeys(funcs ...RateLimitKeyFunc) RateLimitKeyFunc {
+	return func(c echo.Context) (string, error) {
+		keys := make([]string, len(funcs))
+		for n, fn := range funcs {
+			key, err := fn(c)
+			if err != nil || key == "" {
+				return "", err
+			}
+			keys[n] = key
+		}
+		return strings.Join(keys, DefaultKeySeparator), nil
+	}
+}
+
+// headerSeconds formats the duration as the whole seconds rounded up.
+func headerSeconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
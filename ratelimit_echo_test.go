@@ -0,0 +1,135 @@
+package cache
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// newEchoTest returns the echo server serving GET /users/:id through the rate limit middleware.
+func newEchoTest(cfg RateLimitConfig) *echo.Echo {
+	e := echo.New()
+	e.Use(RateLimitMiddleware(cfg))
+	e.GET("/users/:id", func(c echo.Context) error {
+		return c.String(http.StatusOK, "ok")
+	})
+	return e
+}
+
+// serveEcho serves the GET request of the path with the given headers.
+func serveEcho(e *echo.Echo, path string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	for name, val := range headers {
+		req.Header.Set(name, val)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	i := newMemoryTest(t)
+	e := newEchoTest(RateLimitConfig{Limiter: i.WindowLimiter(2, time.Hour)})
+
+	for n := range 2 {
+		rec := serveEcho(e, "/users/1", nil)
+		assert.Equal(t, http.StatusOK, rec.Code, "Request within the limit must pass")
+		assert.Equal(t, "2", rec.Header().Get("RateLimit-Limit"))
+		assert.Equal(t, []string{"1", "0"}[n], rec.Header().Get("RateLimit-Remaining"), "Remaining must decrease")
+		assert.NotEmpty(t, rec.Header().Get("RateLimit-Reset"), "Reset must be set")
+		assert.Empty(t, rec.Header().Get(echo.HeaderRetryAfter), "Retry-After must not be set")
+	}
+	rec := serveEcho(e, "/users/1", nil)
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code, "Request over the limit must be rejected")
+	assert.NotEmpty(t, rec.Header().Get(echo.HeaderRetryAfter), "Retry-After must be set")
+
+	keys, _ := i.GetAllKeys(t.Context(), "{"+KeyRateLimit)
+	if assert.Len(t, keys, 1, "Only one key must be limited") {
+		assert.Equal(t, "192.0.2.1}", keys[0].Prefix, "Client IP must be the default key")
+	}
+}
+
+func TestRateLimitMiddlewareSpoofedIP(t *testing.T) {
+	i := newMemoryTest(t)
+	e := newEchoTest(RateLimitConfig{Limiter: i.WindowLimiter(1, time.Hour)})
+	spoofed := func(ip string) map[string]string {
+		return map[string]string{echo.HeaderXForwardedFor: ip, echo.HeaderXRealIP: ip}
+	}
+	assert.Equal(t, http.StatusOK, serveEcho(e, "/users/1", spoofed("203.0.113.1")).Code, "First request must pass")
+	assert.Equal(t, http.StatusTooManyRequests, serveEcho(e, "/users/1", spoofed("203.0.113.2")).Code,
+		"Spoofed forwarded IP must not dodge the limit")
+
+	// Trusted proxy: the forwarded IP is the client.
+	_, proxyNet, _ := net.ParseCIDR("192.0.2.0/24")
+	e.IPExtractor = echo.ExtractIPFromXFFHeader(echo.TrustIPRange(proxyNet))
+	assert.Equal(t, http.StatusOK, serveEcho(e, "/users/1", spoofed("203.0.113.3")).Code,
+		"Forwarded IP behind the trusted proxy must be the key")
+}
+
+func TestRateLimitMiddlewareGCRA(t *testing.T) {
+	i := newMemoryTest(t)
+	e := newEchoTest(RateLimitConfig{
+		KeyFunc: RateLimitKeyHeader("X-API-Key"),
+		Limiter: i.GCRALimiter(10, time.Minute, 1, 1),
+	})
+	assert.Equal(t, http.StatusBadRequest, serveEcho(e, "/users/1", nil).Code, "Request without the key must be rejected")
+
+	rec := serveEcho(e, "/users/1", map[string]string{"X-API-Key": "a"})
+	assert.Equal(t, http.StatusOK, rec.Code, "Request within the burst must pass")
+	rec = serveEcho(e, "/users/1", map[string]string{"X-API-Key": "a"})
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code, "Request over the burst must be rejected")
+	assert.Equal(t, "6", rec.Header().Get(echo.HeaderRetryAfter), "Retry-After must be the emission interval")
+	rec = serveEcho(e, "/users/1", map[string]string{"X-API-Key": "b"})
+	assert.Equal(t, http.StatusOK, rec.Code, "Other key must be limited on its own")
+}
+
+func TestRateLimitMiddlewareSkipper(t *testing.T) {
+	i := newMemoryTest(t)
+	e := newEchoTest(RateLimitConfig{
+		Skipper: func(c echo.Context) bool { return c.Request().Header.Get("X-Internal") != "" },
+		Limiter: i.WindowLimiter(1, time.Hour),
+	})
+	for range 3 {
+		rec := serveEcho(e, "/users/1", map[string]string{"X-Internal": "1"})
+		assert.Equal(t, http.StatusOK, rec.Code, "Skipped request must pass")
+		assert.Empty(t, rec.Header().Get("RateLimit-Limit"), "Skipped request must not be limited")
+	}
+}
+
+func TestRateLimitMiddlewareError(t *testing.T) {
+	i := newMemoryTest(t)
+	i.store = downStore{i.store}
+	e := newEchoTest(RateLimitConfig{Limiter: i.WindowLimiter(1, time.Hour)})
+	assert.Equal(t, http.StatusOK, serveEcho(e, "/users/1", nil).Code, "Limiter error must let the request through")
+
+	e = newEchoTest(RateLimitConfig{
+		Limiter: i.WindowLimiter(1, time.Hour),
+		ErrorHandler: func(c echo.Context, err error) error {
+			return echo.ErrServiceUnavailable
+		},
+	})
+	assert.Equal(t, http.StatusServiceUnavailable, serveEcho(e, "/users/1", nil).Code, "Limiter error must be handled")
+}
+
+func TestRateLimitKeys(t *testing.T) {
+	e := echo.New()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/users/1", nil), httptest.NewRecorder())
+	c.SetPath("/users/:id")
+	c.Request().RemoteAddr = "192.0.2.1:1234"
+
+	key, err := RateLimitKeys(RateLimitKeyRoute(), RateLimitKeyIP())(c)
+	assert.NoError(t, err, "Key must be no error")
+	assert.Equal(t, "GET /users/:id:192.0.2.1", key, "Keys must be joined")
+
+	_, err = RateLimitKeyUser("user")(c)
+	assert.ErrorIs(t, err, echo.ErrUnauthorized, "Request without the user must be unauthorized")
+	c.Set("user", 42)
+	key, _ = RateLimitKeyUser("user")(c)
+	assert.Equal(t, "42", key, "User must be the key")
+}
@@ -0,0 +1,132 @@
+package cache
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// assertRateLimitWindow runs the sliding window of the limit & asserts only the limit calls are allowed.
+func assertRateLimitWindow(t *testing.T, i *Instance, key string) {
+	t.Helper()
+	for n := range 3 {
+		res, err := i.Set(t.Context(), key).RateLimitWindow(3, time.Hour)
+		assert.NoError(t, err, "RateLimitWindow must be no error")
+		assert.True(t, res.Allowed, "Call within the limit must be allowed")
+		assert.Equal(t, 3, res.Limit)
+		assert.Equal(t, 2-n, res.Remaining, "Remaining must decrease")
+		assert.Zero(t, res.RetryAfter, "Allowed call must not retry")
+		assert.Greater(t, res.ResetAfter, time.Hour, "Reset must wait for the current window to slide out")
+	}
+	res, err := i.Set(t.Context(), key).RateLimitWindow(3, time.Hour)
+	assert.NoError(t, err, "RateLimitWindow must be no error")
+	assert.False(t, res.Allowed, "Call over the limit must be blocked")
+	assert.Zero(t, res.Remaining, "Remaining must be zero")
+	assert.Greater(t, res.RetryAfter, time.Duration(0), "Blocked call must retry after")
+}
+
+func TestRateLimitWindow(t *testing.T) {
+	i := newMemoryTest(t)
+	assertRateLimitWindow(t, i, testKey)
+
+	keys, err := i.GetAllKeys(t.Context(), "{"+KeyRateLimit)
+	assert.NoError(t, err, "GetAllKeys must be no error")
+	assert.Len(t, keys, 1, "Only the current window counter must be stored")
+}
+
+func TestRateLimitWindowRedis(t *testing.T) {
+	i := newRedisTest(t)
+	assertRateLimitWindow(t, i, "LimitWindow"+strconv.FormatInt(time.Now().UnixNano(), 10))
+}
+
+func TestRateLimitWindowInvalid(t *testing.T) {
+	i := newMemoryTest(t)
+	_, err := i.Set(t.Context(), testKey).RateLimitWindow(0, time.Minute)
+	assert.ErrorIs(t, err, ErrRateLimitInvalid, "Zero limit must be invalid")
+	_, err = i.Set(t.Context(), "").RateLimitWindow(1, time.Minute)
+	assert.ErrorIs(t, err, ErrEmptyKey, "Empty key must be error")
+
+	i.store = downStore{i.store}
+	_, err = i.Set(t.Context(), testKey).RateLimitWindow(1, time.Minute)
+	assert.ErrorIs(t, err, ErrRateLimitNotSupported, "Store without RateLimiter must not be supported")
+}
+
+// assertRateLimitGCRA runs the GCRA of 10 calls per hour with burst of 3 & asserts only the burst is allowed.
+func assertRateLimitGCRA(t *testing.T, i *Instance, key string) {
+	t.Helper()
+	for n := range 3 {
+		res, err := i.Set(t.Context(), key).RateLimitGCRA(10, time.Hour, 3, 1)
+		assert.NoError(t, err, "RateLimitGCRA must be no error")
+		assert.True(t, res.Allowed, "Call within the burst must be allowed")
+		assert.Equal(t, 3, res.Limit, "Limit must be the burst")
+		assert.Equal(t, 2-n, res.Remaining, "Remaining must decrease")
+		assert.Zero(t, res.RetryAfter, "Allowed call must not retry")
+	}
+	res, err := i.Set(t.Context(), key).RateLimitGCRA(10, time.Hour, 3, 1)
+	assert.NoError(t, err, "RateLimitGCRA must be no error")
+	assert.False(t, res.Allowed, "Call over the burst must be blocked")
+	assert.Zero(t, res.Remaining, "Remaining must be zero")
+	assert.InDelta(t, float64(6*time.Minute), float64(res.RetryAfter), float64(time.Second), "Retry must wait for one emission interval")
+	assert.InDelta(t, float64(18*time.Minute), float64(res.ResetAfter), float64(time.Second), "Reset must wait for the whole burst")
+}
+
+func TestRateLimitGCRA(t *testing.T) {
+	assertRateLimitGCRA(t, newMemoryTest(t), testKey)
+}
+
+func TestRateLimitGCRARedis(t *testing.T) {
+	i := newRedisTest(t)
+	assertRateLimitGCRA(t, i, "LimitGCRA"+strconv.FormatInt(time.Now().UnixNano(), 10))
+}
+
+func TestRateLimitGCRACost(t *testing.T) {
+	i := newMemoryTest(t)
+	res, err := i.Set(t.Context(), testKey).RateLimitGCRA(10, time.Hour, 3, 2)
+	assert.NoError(t, err, "RateLimitGCRA must be no error")
+	assert.True(t, res.Allowed, "Weighted call within the burst must be allowed")
+	assert.Equal(t, 1, res.Remaining, "Weighted call must consume its cost")
+
+	res, _ = i.Set(t.Context(), testKey).RateLimitGCRA(10, time.Hour, 3, 2)
+	assert.False(t, res.Allowed, "Weighted call over the remaining must be blocked")
+	res, _ = i.Set(t.Context(), testKey).RateLimitGCRA(10, time.Hour, 3, 1)
+	assert.True(t, res.Allowed, "Lighter call within the remaining must be allowed")
+
+	_, err = i.Set(t.Context(), testKey).RateLimitGCRA(10, time.Hour, 3, 4)
+	assert.ErrorIs(t, err, ErrRateLimitInvalid, "Cost over the burst must be invalid")
+}
+
+func TestGCRAResult(t *testing.T) {
+	// Emission 10us, burst 5, TAT 20us ahead: 3 units left, back to full after 20us.
+	res := gcraResult(5, 10, 50, 10, 100, true, 120)
+	assert.Equal(t, 3, res.Remaining)
+	assert.Equal(t, 20*time.Microsecond, res.ResetAfter)
+
+	// TAT 45us ahead, a call of 2 units fits once the TAT is 30us ahead.
+	res = gcraResult(5, 10, 50, 20, 100, false, 145)
+	assert.Zero(t, res.Remaining)
+	assert.Equal(t, 15*time.Microsecond, res.RetryAfter)
+}
+
+func TestSlidingWindowResult(t *testing.T) {
+	window := 10 * time.Second
+
+	// Half of the previous window still overlaps: 4*0.5 + 1 = 3 of 5.
+	res := slidingWindowResult(5, window, 5*time.Second, true, 1, 4)
+	assert.Equal(t, 2, res.Remaining)
+	assert.Equal(t, 15*time.Second, res.ResetAfter, "Reset must wait for the current window to slide out")
+
+	// 4*0.5 + 3 = 5 of 5, one more call fits once the previous count drops to 1.
+	res = slidingWindowResult(5, window, 5*time.Second, false, 3, 4)
+	assert.Zero(t, res.Remaining)
+	assert.Equal(t, 2500*time.Millisecond, res.RetryAfter, "Retry must wait for the previous window to slide out")
+
+	// Current window alone is full, one more call fits once the current window count drops to 4.
+	res = slidingWindowResult(5, window, 5*time.Second, false, 5, 0)
+	assert.Equal(t, 7*time.Second, res.RetryAfter, "Retry must wait for the current window to slide out")
+
+	// Only the previous window left.
+	res = slidingWindowResult(5, window, 2*time.Second, true, 0, 1)
+	assert.Equal(t, 8*time.Second, res.ResetAfter, "Reset must wait for the previous window to slide out")
+}
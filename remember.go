@@ -0,0 +1,264 @@
+package cache
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// rememberLockPoll defines how often the Remember lock waiter polls the value.
+const rememberLockPoll = 50 * time.Millisecond
+
+// rememberUnlockTimeout defines timeout to release the Remember lock.
+const rememberUnlockTimeout = time.Second
+
+// rememberRefreshTimeout defines timeout of the background refresh backend operations.
+const rememberRefreshTimeout = 10 * time.Second
+
+// rememberLoadTimeout defines min timeout of the load shared by the concurrent Remember calls, it is detached
+// from the context of the caller starting it & extended up to the latest deadline of that caller.
+const rememberLoadTimeout = 10 * time.Second
+
+// remembered is the result of the coalesced Remember calls, either the value returned by the loader
+// or the raw value stored by the other lock holder.
+type remembered struct {
+	val any
+	raw []byte
+}
+
+// remember loads the missing key through the loader & stores it to the backend store. Concurrent calls
+// of the same key share a single load, with the distributed lock only the lock holder across instances
+// calls the loader while the others wait for the value.
+func (i *Instance) remember(get *getter, key string, out any, rem RememberContextFn) error {
+	// Check out must be pointer.
+	outVal := reflect.ValueOf(out)
+	if outVal.Kind() != reflect.Ptr || outVal.IsNil() {
+		return errors.New("out must be a non-nil pointer")
+	}
+	outType := outVal.Elem().Type()
+
+	// The shared load runs on a copy of the getter detached from the caller starting it, so its cancellation
+	// does not fail the other callers, every caller stops waiting once its own context is done.
+	load := *get
+	ch := i.flight.DoChan(get.key, func() (any, error) {
+		timeout := rememberLoadTimeout
+		if deadline, ok := load.ctx.Deadline(); ok {
+			timeout = max(timeout, time.Until(deadline))
+		}
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(load.ctx), timeout)
+		defer cancel()
+		load.ctx, load.cancel = ctx, nil
+		if load.lock || i.cfg.RememberLock {
+			return i.loadLocked(&load, key, outType, rem)
+		}
+		return i.load(&load, key, outType, rem)
+	})
+	var shared singleflight.Result
+	select {
+	case shared = <-ch:
+	case <-get.ctx.Done():
+		return get.ctx.Err()
+	}
+	if shared.Err != nil {
+		return shared.Err
+	}
+
+	// Ok.
+	res := shared.Val.(*remembered)
+	if res.raw != nil {
+		codec := i.valueCodec(get.codec)
+		if err := decodeValue(codec, res.raw, out, i.frameOptions(get.key, codec, get.version, nil, "")); err != nil {
+			if errors.Is(err, ErrNotFound) {
+				return err
+			}
+			return decodeError("get", key, out, err)
+		}
+		return nil
+	}
+	valVal, err := rememberValue(outType, res.val)
+	if err != nil {
+		return err
+	}
+	outVal.Elem().Set(valVal)
+	return nil
+}
+
+// load calls the loader & stores its value to the backend store.
+func (i *Instance) load(get *getter, key string, outType reflect.Type, rem RememberContextFn) (*remembered, error) {
+	// Call closure function to get the default value.
+	start := time.Now()
+	forever, val, err := i.callLoader(get.ctx, rem)
+	if errors.Is(err, ErrNotFound) {
+		// Negative cache, the tombstone is best effort.
+		i.write(get.ctx, get.key, tombstone(), cmp.Or(max(get.negativeTTL, 0), i.cfg.NegativeTTL))
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+	delta := time.Since(start)
+
+	// Make sure val can be assigned to the out.
+	if _, err := rememberValue(outType, val); err != nil {
+		return nil, err
+	}
+
+	// Store value into cache, the value carries the soft expiration & the recompute time
+	// when the soft TTL or the early refresh is used.
+	set := i.Set(get.ctx, key).SetTTL(get.ttl).SetCodec(get.codec).SetVersion(get.version)
+	if get.softTTL > 0 || get.beta > 0 {
+		set.meta = &valueMeta{delta: delta}
+		switch {
+		case get.softTTL > 0:
+			set.meta.softExpire = time.Now().Add(get.softTTL).UnixNano()
+		case !forever:
+			set.meta.softExpire = time.Now().Add(cmp.Or(max(get.ttl, 0), DefaultTTL)).UnixNano()
+		}
+	}
+	if forever {
+		_, err = set.PutForever(val)
+	} else {
+		_, err = set.Put(val)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &remembered{val: val}, nil
+}
+
+// loadLocked loads the key while holding the distributed lock. Without the lock the caller waits for
+// the lock holder to store the value, then loads the key by itself once the lock is released without value
+// (e.g. the loader fails), expires (e.g. the holder dies) or the wait times out.
+func (i *Instance) loadLocked(get *getter, key string, outType reflect.Type, rem RememberContextFn) (*remembered, error) {
+	lockKey, lockTTL, wait := i.rememberLock(get, key)
+	token := []byte(newInstanceID())
+
+	deadline := time.Now().Add(wait)
+	for time.Now().Before(deadline) {
+		acquired, err := i.store.SetNX(get.ctx, lockKey, token, lockTTL)
+		if err != nil {
+			break
+		}
+		if acquired {
+			defer i.unlock(get.ctx, lockKey, token)
+
+			// The value may be stored by the previous lock holder in the meantime.
+			if b, err := i.store.Get(get.ctx, get.key); err == nil {
+				return &remembered{raw: b}, nil
+			}
+			return i.load(get, key, outType, rem)
+		}
+
+		b, err := i.waitLocked(get.ctx, get.key, lockKey, deadline)
+		if err != nil {
+			return nil, opError("get", key, err)
+		}
+		if b != nil {
+			return &remembered{raw: b}, nil
+		}
+	}
+
+	// Lock wait timeout or the lock is unavailable, load it anyway.
+	return i.load(get, key, outType, rem)
+}
+
+// refresh reloads the key in the background while the stale value is served, only one refresh of the key
+// runs in the process. With the distributed lock the refresh is skipped when the other instance holds the lock.
+func (i *Instance) refresh(get *getter, key string, outType reflect.Type, rem RememberContextFn) {
+	if _, running := i.refreshing.LoadOrStore(get.key, struct{}{}); running {
+		return
+	}
+	bg := *get
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(get.ctx), rememberRefreshTimeout)
+	bg.ctx, bg.cancel = ctx, nil
+	go func() {
+		defer i.refreshing.Delete(bg.key)
+		defer cancel()
+		if bg.lock || i.cfg.RememberLock {
+			lockKey, lockTTL, _ := i.rememberLock(&bg, key)
+			token := []byte(newInstanceID())
+			if acquired, err := i.store.SetNX(ctx, lockKey, token, lockTTL); err != nil || !acquired {
+				return
+			}
+			defer i.unlock(ctx, lockKey, token)
+		}
+		i.load(&bg, key, outType, rem)
+	}()
+}
+
+// rememberLock returns the lock key, the lock TTL & the lock wait of the Remember call.
+func (i *Instance) rememberLock(get *getter, key string) (lockKey string, ttl, wait time.Duration) {
+	lockKey = i.cfg.Namespace + DefaultKeySeparator + KeyLock + DefaultKeySeparator + key
+	ttl, wait = i.cfg.RememberLockTTL, i.cfg.RememberLockWait
+	if get.lockTTL > 0 {
+		ttl = get.lockTTL
+	}
+	if get.lockWait > 0 {
+		wait = get.lockWait
+	}
+	return lockKey, ttl, wait
+}
+
+// waitLocked polls the value of the key until it is stored, the lock is gone or the deadline is reached.
+// Nil value means the value is not stored by the lock holder.
+func (i *Instance) waitLocked(ctx context.Context, key, lockKey string, deadline time.Time) ([]byte, error) {
+	ticker := time.NewTicker(rememberLockPoll)
+	defer ticker.Stop()
+	for time.Now().Before(deadline) {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+		b, err := i.store.Get(ctx, key)
+		if err == nil {
+			return b, nil
+		}
+		if !isMiss(err) {
+			continue
+		}
+		if n, err := i.store.Exists(ctx, lockKey); err == nil && n == 0 {
+			return nil, nil
+		}
+	}
+	return nil, nil
+}
+
+// unlock releases the lock only if it is still owned by the token, the lock expires by itself
+// when the release fails.
+func (i *Instance) unlock(ctx context.Context, lockKey string, token []byte) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), rememberUnlockTimeout)
+	defer cancel()
+	if locker, ok := i.store.(Locker); ok {
+		locker.Unlock(ctx, lockKey, token)
+		return
+	}
+	if b, err := i.store.Get(ctx, lockKey); err == nil && string(b) == string(token) {
+		i.store.Del(ctx, lockKey)
+	}
+}
+
+// rememberValue returns the loaded value that assignable to the out type.
+func rememberValue(outType reflect.Type, val any) (reflect.Value, error) {
+	valVal := reflect.ValueOf(val)
+	if !valVal.IsValid() {
+		switch outType.Kind() {
+		case reflect.Interface, reflect.Ptr, reflect.Map, reflect.Slice:
+			return reflect.Zero(outType), nil
+		default:
+			return reflect.Value{}, fmt.Errorf("cannot assign nil value to out of type %s", outType)
+		}
+	}
+	if !valVal.Type().AssignableTo(outType) {
+		return reflect.Value{}, fmt.Errorf(
+			"cannot assign value of type %s to out of type %s",
+			valVal.Type(), outType,
+		)
+	}
+	return valVal, nil
+}
@@ -0,0 +1,270 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// rememberConcurrently calls Remember of the same key from n goroutines, the loader sleeps
+// so the calls overlap. It returns how many times the loader is called.
+func rememberConcurrently(t *testing.T, n int, get func(idx int) *getter) int64 {
+	t.Helper()
+	var (
+		calls atomic.Int64
+		wg    sync.WaitGroup
+	)
+	for idx := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var val string
+			err := get(idx).Remember(&val, func() (bool, any, error) {
+				calls.Add(1)
+				time.Sleep(100 * time.Millisecond)
+				return false, testValue, nil
+			})
+			assert.NoError(t, err, "Remember must be no error")
+			assert.Equal(t, testValue, val, "Every caller must receive the loaded value")
+		}()
+	}
+	wg.Wait()
+	return calls.Load()
+}
+
+func TestRememberSingleflight(t *testing.T) {
+	i := newMemoryTest(t)
+	calls := rememberConcurrently(t, 50, func(int) *getter {
+		return i.Get(t.Context(), testKey, testPrefix)
+	})
+	assert.Equal(t, int64(1), calls, "Loader must be called once per process")
+}
+
+func TestRememberSingleflightCanceled(t *testing.T) {
+	i := newMemoryTest(t)
+	started, release := make(chan struct{}), make(chan struct{})
+	loader := func() (bool, any, error) {
+		close(started)
+		<-release
+		return false, testValue, nil
+	}
+
+	// The caller starting the load is canceled while the load is running.
+	ctx, cancel := context.WithCancel(t.Context())
+	first := make(chan error, 1)
+	go func() {
+		var val string
+		first <- i.Get(ctx, testKey, testPrefix).Remember(&val, loader)
+	}()
+	<-started
+	second := make(chan error, 1)
+	var val string
+	go func() {
+		second <- i.Get(t.Context(), testKey, testPrefix).Remember(&val, loader)
+	}()
+	cancel()
+	assert.ErrorIs(t, <-first, context.Canceled, "Canceled caller must stop waiting")
+
+	close(release)
+	assert.NoError(t, <-second, "Other caller must not fail with the canceled context")
+	assert.Equal(t, testValue, val, "Other caller must receive the loaded value")
+}
+
+func TestRememberLockAcrossInstances(t *testing.T) {
+	store := newMemoryStore(0, 0, LocalPolicyLRU)
+	t.Cleanup(func() { store.Close() })
+	newMemoryTest(t)
+	a, b := New().SetStore(store), New().SetStore(store)
+	calls := rememberConcurrently(t, 20, func(idx int) *getter {
+		i := a
+		if idx%2 == 1 {
+			i = b
+		}
+		return i.Get(t.Context(), testKey, testPrefix).Lock(time.Second, time.Second)
+	})
+	assert.Equal(t, int64(1), calls, "Loader must be called once across instances")
+
+	lockKey := a.cfg.Namespace + DefaultKeySeparator + KeyLock + DefaultKeySeparator +
+		testPrefix + DefaultKeySeparator + testKey
+	n, _ := store.Exists(t.Context(), lockKey)
+	assert.Zero(t, n, "Lock must be released")
+}
+
+func TestRememberLockWaitTimeout(t *testing.T) {
+	i := newMemoryTest(t)
+	lockKey := i.cfg.Namespace + DefaultKeySeparator + KeyLock + DefaultKeySeparator + testKey
+	i.store.Set(t.Context(), lockKey, []byte("dead-holder"), time.Minute)
+
+	var val string
+	start := time.Now()
+	err := i.Get(t.Context(), testKey).Lock(0, 200*time.Millisecond).Remember(&val, func() (bool, any, error) {
+		return false, testValue, nil
+	})
+	assert.NoError(t, err, "Remember must fall back to the loader")
+	assert.Equal(t, testValue, val, "Value should be loaded after the lock wait timeout")
+	assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond, "Remember must wait for the lock holder")
+
+	b, _ := i.store.Get(t.Context(), lockKey)
+	assert.Equal(t, "dead-holder", string(b), "Lock of the other holder must not be released")
+}
+
+func TestRememberLockExpired(t *testing.T) {
+	i := newMemoryTest(t)
+	lockKey := i.cfg.Namespace + DefaultKeySeparator + KeyLock + DefaultKeySeparator + testKey
+	i.store.Set(t.Context(), lockKey, []byte("dead-holder"), 100*time.Millisecond)
+
+	var val string
+	start := time.Now()
+	err := i.Get(t.Context(), testKey).Lock(0, 5*time.Second).Remember(&val, func() (bool, any, error) {
+		return false, testValue, nil
+	})
+	assert.NoError(t, err, "Remember must acquire the expired lock")
+	assert.Equal(t, testValue, val, "Value should be loaded by the new lock holder")
+	assert.Less(t, time.Since(start), time.Second, "Remember must not wait until the wait timeout")
+}
+
+func TestRememberLockValueStoredByHolder(t *testing.T) {
+	i := newMemoryTest(t)
+	lockKey := i.cfg.Namespace + DefaultKeySeparator + KeyLock + DefaultKeySeparator + testKey
+	i.store.Set(t.Context(), lockKey, []byte("holder"), time.Minute)
+	time.AfterFunc(100*time.Millisecond, func() {
+		i.store.Set(t.Context(), i.cfg.Namespace+DefaultKeySeparator+testKey, []byte("from-holder"), time.Minute)
+	})
+
+	var val string
+	err := i.Get(t.Context(), testKey).Lock(0, 5*time.Second).Remember(&val, func() (bool, any, error) {
+		return false, testValue, nil
+	})
+	assert.NoError(t, err, "Remember must be no error")
+	assert.Equal(t, "from-holder", val, "Value should be the one stored by the lock holder")
+}
+
+func TestRememberLoaderErrorReleasesLock(t *testing.T) {
+	i := newMemoryTest(t)
+	var val string
+	errLoad := errors.New("load failed")
+	err := i.Get(t.Context(), testKey).Lock(time.Minute, 0).Remember(&val, func() (bool, any, error) {
+		return false, nil, errLoad
+	})
+	assert.ErrorIs(t, err, errLoad, "Loader error must be returned")
+
+	lockKey := i.cfg.Namespace + DefaultKeySeparator + KeyLock + DefaultKeySeparator + testKey
+	n, _ := i.store.Exists(t.Context(), lockKey)
+	assert.Zero(t, n, "Lock must be released after the loader error")
+}
+
+func TestMemoryStoreUnlock(t *testing.T) {
+	store := newMemoryStore(0, 0, LocalPolicyLRU)
+	defer store.Close()
+	store.Set(t.Context(), "lock", []byte("a"), time.Minute)
+
+	ok, err := store.Unlock(t.Context(), "lock", []byte("b"))
+	assert.NoError(t, err, "Unlock must be no error")
+	assert.False(t, ok, "Lock of the other token must not be released")
+	ok, _ = store.Unlock(t.Context(), "lock", []byte("a"))
+	assert.True(t, ok, "Lock of the token must be released")
+}
+
+func TestRememberSoftTTL(t *testing.T) {
+	i := newMemoryTest(t)
+	var calls atomic.Int64
+	remember := func() string {
+		var val string
+		err := i.Get(t.Context(), testKey).SetTTL(time.Minute).SetSoftTTL(50*time.Millisecond).
+			Remember(&val, func() (bool, any, error) {
+				return false, fmt.Sprintf("v%d", calls.Add(1)), nil
+			})
+		assert.NoError(t, err, "Remember must be no error")
+		return val
+	}
+	assert.Equal(t, "v1", remember(), "First Remember must load the value")
+	assert.Equal(t, "v1", remember(), "Fresh value must be served from the cache")
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, "v1", remember(), "Stale value must be served immediately")
+	assert.Eventually(t, func() bool {
+		var val string
+		i.Get(t.Context(), testKey).Pull(&val)
+		return val == "v2"
+	}, time.Second, 10*time.Millisecond, "Stale value must be refreshed in the background")
+	assert.Equal(t, int64(2), calls.Load(), "Loader must be called once for the refresh")
+}
+
+func TestRememberEarlyRefresh(t *testing.T) {
+	i := newMemoryTest(t)
+	var calls atomic.Int64
+	remember := func() {
+		var val string
+		err := i.Get(t.Context(), testKey).SetTTL(time.Minute).EarlyRefresh(1).
+			Remember(&val, func() (bool, any, error) {
+				calls.Add(1)
+				time.Sleep(10 * time.Millisecond)
+				return false, testValue, nil
+			})
+		assert.NoError(t, err, "Remember must be no error")
+	}
+	remember()
+	remember()
+	assert.Equal(t, int64(1), calls.Load(), "Far expiration must not be refreshed early")
+
+	// Pretend the loader takes as long as the TTL, so the early refresh must fire.
+	key := i.cfg.Namespace + DefaultKeySeparator + testKey
+	b, _ := i.store.Get(t.Context(), key)
+	payload, meta, _ := decodeFrame(b, frameOptions{})
+	meta.delta = time.Hour
+	b, _ = encodeFrame(payload, frameOptions{meta: meta})
+	i.store.Set(t.Context(), key, b, time.Minute)
+	remember()
+	assert.Eventually(t, func() bool {
+		return calls.Load() == 2
+	}, time.Second, 10*time.Millisecond, "Expensive value must be refreshed early")
+}
+
+func TestRememberNegativeCache(t *testing.T) {
+	i := newMemoryTest(t)
+	var calls atomic.Int64
+	remember := func() error {
+		var val string
+		return i.Get(t.Context(), testKey).SetNegativeTTL(100*time.Millisecond).
+			Remember(&val, func() (bool, any, error) {
+				calls.Add(1)
+				return false, nil, fmt.Errorf("user 123: %w", ErrNotFound)
+			})
+	}
+	assert.ErrorIs(t, remember(), ErrNotFound, "Loader ErrNotFound must be returned")
+	assert.ErrorIs(t, remember(), ErrNotFound, "Tombstone must return ErrNotFound")
+	assert.Equal(t, int64(1), calls.Load(), "Loader must not be called while the tombstone exists")
+
+	var val string
+	err := i.Get(t.Context(), testKey).Pull(&val)
+	assert.ErrorIs(t, err, ErrNotFound, "Pull of tombstone must return ErrNotFound")
+
+	var vals map[string]string
+	res, err := i.GetMany(t.Context(), []string{testKey}).Pull(&vals)
+	assert.NoError(t, err, "GetMany must be no error")
+	assert.ErrorIs(t, res.Errors[testKey], ErrNotFound, "GetMany of tombstone must report ErrNotFound")
+
+	time.Sleep(150 * time.Millisecond)
+	assert.ErrorIs(t, remember(), ErrNotFound, "Loader ErrNotFound must be returned")
+	assert.Equal(t, int64(2), calls.Load(), "Loader must be called after the negative TTL")
+}
+
+func TestRememberNegativeCacheOverwritten(t *testing.T) {
+	i := newMemoryTest(t)
+	var val string
+	err := i.Get(t.Context(), testKey).Remember(&val, func() (bool, any, error) {
+		return false, nil, ErrNotFound
+	})
+	assert.ErrorIs(t, err, ErrNotFound, "Loader ErrNotFound must be returned")
+
+	i.Set(t.Context(), testKey).Put(testValue)
+	err = i.Get(t.Context(), testKey).Pull(&val)
+	assert.NoError(t, err, "Pull must be no error after the tombstone is overwritten")
+	assert.Equal(t, testValue, val, "Value should be the overwritten value")
+}
@@ -0,0 +1,173 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/qoinlyid/qore"
+)
+
+// semaphorePoll defines how often the blocking Acquire retries while there is no permit left.
+const semaphorePoll = 50 * time.Millisecond
+
+// Permit is a lease of the distributed semaphore, it is held until it is released or its lease expires.
+type Permit struct {
+	i        *Instance
+	key      string // Key without the namespace.
+	storeKey string
+	id       string
+}
+
+// Acquire blocks until a permit of the named semaphore is acquired, at most limit permits are held at once
+// across all instances. The permit expires after leaseTTL (at least 1ms) unless it is extended, so the permits
+// of a crashed holder are reclaimed automatically. The lease expiry follows the store clock (redis TIME). The context error
+// is returned when ctx is done before a permit is left, a nil ctx waits without deadline. The store must
+// implement Leaser, otherwise ErrSemaphoreNotSupported is returned.
+//
+//	permit, err := cache.Acquire(ctx, "export:tenant-42", 5, time.Minute)
+//	if err != nil {
+//		return err
+//	}
+//	defer permit.Release(ctx)
+func (i *Instance) Acquire(ctx context.Context, name string, limit int, leaseTTL time.Duration) (*Permit, error) {
+	return i.acquire(ctx, name, limit, leaseTTL, true)
+}
+
+// TryAcquire acquires a permit of the named semaphore like Acquire without waiting,
+// ErrSemaphoreFull is returned when there is no permit left. If the provided context is nil,
+// a new background context with a default timeout will be created.
+//
+//	permit, err := cache.TryAcquire(ctx, "export:tenant-42", 5, time.Minute)
+//	if errors.Is(err, cache.ErrSemaphoreFull) {
+//		log.Println("too many exports in flight")
+//	}
+func (i *Instance) TryAcquire(ctx context.Context, name string, limit int, leaseTTL time.Duration) (*Permit, error) {
+	return i.acquire(ctx, name, limit, leaseTTL, false)
+}
+
+// Extend renews the lease of the permit for the given ttl from now, ErrPermitLost is returned
+// when the permit is already expired or released. If the provided context is nil, a new background
+// context with a default timeout will be created.
+//
+//	if err := permit.Extend(ctx, time.Minute); err != nil {
+//		log.Println(err)
+//	}
+func (p *Permit) Extend(ctx context.Context, ttl time.Duration) error {
+	if ctx == nil {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+	}
+	op := &operation{name: OpExtend, key: p.key, prefix: KeySemaphore, ttl: ttl}
+	return p.i.instrument(ctx, op, func(ctx context.Context) error {
+		leaser, err := p.i.leaser()
+		if err != nil {
+			return err
+		}
+		// The leases are kept in milliseconds.
+		if ttl < time.Millisecond {
+			return ErrSemaphoreInvalid
+		}
+		held, err := leaser.ExtendLease(ctx, p.storeKey, p.id, ttl)
+		if err != nil {
+			return opError("extend", p.key, err)
+		}
+		if !held {
+			return ErrPermitLost
+		}
+		return nil
+	})
+}
+
+// Release gives the permit back to the semaphore, ErrPermitLost is returned when the permit
+// is already expired or released. If the provided context is nil, a new background context
+// with a default timeout will be created.
+//
+//	defer permit.Release(ctx)
+func (p *Permit) Release(ctx context.Context) error {
+	if ctx == nil {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+	}
+	op := &operation{name: OpRelease, key: p.key, prefix: KeySemaphore}
+	return p.i.instrument(ctx, op, func(ctx context.Context) error {
+		leaser, err := p.i.leaser()
+		if err != nil {
+			return err
+		}
+		held, err := leaser.ReleaseLease(ctx, p.storeKey, p.id)
+		if err != nil {
+			return opError("release", p.key, err)
+		}
+		if !held {
+			return ErrPermitLost
+		}
+		return nil
+	})
+}
+
+// leaser returns the Leaser of the backend store.
+func (i *Instance) leaser() (Leaser, error) {
+	if err := i.validateStore(); err != nil {
+		return nil, err
+	}
+	leaser, ok := i.store.(Leaser)
+	if !ok {
+		return nil, ErrSemaphoreNotSupported
+	}
+	return leaser, nil
+}
+
+// acquire helper to add the lease of the named semaphore, it polls until ctx is done when wait is set.
+func (i *Instance) acquire(
+	ctx context.Context, name string, limit int, leaseTTL time.Duration, wait bool,
+) (permit *Permit, err error) {
+	// The blocking acquire waits as long as it takes, the others use the default timeout.
+	if ctx == nil {
+		ctx = context.Background()
+		if !wait {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, time.Second)
+			defer cancel()
+		}
+	}
+	key := KeySemaphore + DefaultKeySeparator + name
+	op := &operation{name: OpAcquire, key: key, prefix: KeySemaphore, ttl: leaseTTL}
+	err = i.instrument(ctx, op, func(ctx context.Context) error {
+		// Validate.
+		leaser, err := i.leaser()
+		if err != nil {
+			return err
+		}
+		if qore.ValidationIsEmpty(name) {
+			return ErrEmptyKey
+		}
+		if limit <= 0 || leaseTTL < time.Millisecond {
+			return ErrSemaphoreInvalid
+		}
+
+		p := &Permit{i: i, key: key, storeKey: i.cfg.Namespace + DefaultKeySeparator + key, id: newInstanceID()}
+		ticker := time.NewTicker(semaphorePoll)
+		defer ticker.Stop()
+		for {
+			acquired, err := leaser.AcquireLease(ctx, p.storeKey, p.id, int64(limit), leaseTTL)
+			if err != nil {
+				return opError("acquire", key, err)
+			}
+			if acquired {
+				permit = p
+				return nil
+			}
+			if !wait {
+				return ErrSemaphoreFull
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-ticker.C:
+			}
+		}
+	})
+	return
+}
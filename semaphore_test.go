@@ -0,0 +1,99 @@
+package cache
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// assertSemaphore asserts only the limit permits of the semaphore are held at once.
+func assertSemaphore(t *testing.T, i *Instance, name string) {
+	t.Helper()
+	ctx := t.Context()
+	first, err := i.TryAcquire(ctx, name, 2, time.Minute)
+	if !assert.NoError(t, err, "TryAcquire must be no error") {
+		return
+	}
+	_, err = i.TryAcquire(ctx, name, 2, time.Minute)
+	assert.NoError(t, err, "TryAcquire within the limit must be no error")
+	_, err = i.TryAcquire(ctx, name, 2, time.Minute)
+	assert.ErrorIs(t, err, ErrSemaphoreFull, "TryAcquire over the limit must be full")
+
+	assert.NoError(t, first.Extend(ctx, time.Minute), "Extend must be no error")
+	assert.NoError(t, first.Release(ctx), "Release must be no error")
+	assert.ErrorIs(t, first.Release(ctx), ErrPermitLost, "Released permit must be lost")
+	_, err = i.TryAcquire(ctx, name, 2, time.Minute)
+	assert.NoError(t, err, "Released permit must be acquired again")
+}
+
+func TestSemaphore(t *testing.T) {
+	assertSemaphore(t, newMemoryTest(t), testKey)
+}
+
+func TestSemaphoreRedis(t *testing.T) {
+	i := newRedisTest(t)
+	assertSemaphore(t, i, "Semaphore"+strconv.FormatInt(time.Now().UnixNano(), 10))
+}
+
+func TestSemaphoreLeaseExpiry(t *testing.T) {
+	i := newMemoryTest(t)
+	ctx := t.Context()
+	crashed, err := i.TryAcquire(ctx, testKey, 1, 50*time.Millisecond)
+	assert.NoError(t, err, "TryAcquire must be no error")
+
+	time.Sleep(80 * time.Millisecond)
+	_, err = i.TryAcquire(ctx, testKey, 1, time.Minute)
+	assert.NoError(t, err, "Expired permit must be reclaimed")
+	assert.ErrorIs(t, crashed.Extend(ctx, time.Minute), ErrPermitLost, "Expired permit must not be extended")
+	assert.ErrorIs(t, crashed.Release(ctx), ErrPermitLost, "Expired permit must be lost")
+}
+
+func TestSemaphoreAcquireWait(t *testing.T) {
+	i := newMemoryTest(t)
+	held, err := i.Acquire(t.Context(), testKey, 1, time.Minute)
+	assert.NoError(t, err, "Acquire must be no error")
+
+	ctx, cancel := context.WithTimeout(t.Context(), 100*time.Millisecond)
+	defer cancel()
+	_, err = i.Acquire(ctx, testKey, 1, time.Minute)
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "Acquire must honor the context")
+
+	go func() {
+		time.Sleep(60 * time.Millisecond)
+		held.Release(context.Background())
+	}()
+	permit, err := i.Acquire(t.Context(), testKey, 1, time.Minute)
+	assert.NoError(t, err, "Acquire must wait for the released permit")
+	assert.NotNil(t, permit, "Permit must be returned")
+}
+
+func TestSemaphoreInvalid(t *testing.T) {
+	i := newMemoryTest(t)
+	_, err := i.TryAcquire(t.Context(), testKey, 0, time.Minute)
+	assert.ErrorIs(t, err, ErrSemaphoreInvalid, "Zero limit must be invalid")
+	_, err = i.TryAcquire(t.Context(), testKey, 1, time.Microsecond)
+	assert.ErrorIs(t, err, ErrSemaphoreInvalid, "Lease TTL below a millisecond must be invalid")
+	permit, err := i.TryAcquire(t.Context(), testKey, 1, time.Minute)
+	assert.NoError(t, err, "TryAcquire must be no error")
+	assert.ErrorIs(t, permit.Extend(t.Context(), time.Microsecond), ErrSemaphoreInvalid, "Extend TTL below a millisecond must be invalid")
+	_, err = i.TryAcquire(t.Context(), "", 1, time.Minute)
+	assert.ErrorIs(t, err, ErrEmptyKey, "Empty name must be error")
+
+	i.store = downStore{i.store}
+	_, err = i.TryAcquire(t.Context(), testKey, 1, time.Minute)
+	assert.ErrorIs(t, err, ErrSemaphoreNotSupported, "Store without Leaser must not be supported")
+}
+
+func TestSemaphoreNilContext(t *testing.T) {
+	i := newMemoryTest(t)
+	permit, err := i.TryAcquire(nil, testKey, 1, time.Minute)
+	assert.NoError(t, err, "TryAcquire with nil ctx must be no error")
+	assert.NoError(t, permit.Extend(nil, time.Minute), "Extend with nil ctx must be no error")
+	assert.NoError(t, permit.Release(nil), "Release with nil ctx must be no error")
+	permit, err = i.Acquire(nil, testKey, 1, time.Minute)
+	assert.NoError(t, err, "Acquire with nil ctx must be no error")
+	assert.NotNil(t, permit, "Permit must be returned")
+}
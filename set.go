@@ -2,19 +2,32 @@ package cache
 
 import (
 	"context"
+	"strings"
 	"time"
 
 	"github.com/qoinlyid/qore"
-	"github.com/redis/go-redis/v9"
 )
 
 // setter is a method-chaining configuration struct for cache store operations.
 type setter struct {
 	base
-	ttl time.Duration
+	ttl         time.Duration
+	codec       Codec
+	compression string
+	version     uint32
+	meta        *valueMeta
 
 	// setFn is a closure function that called to stores cache in the backend.
-	setFn func(set *setter, val any, borrow ...bool) (redis.UniversalClient, error)
+	setFn func(set *setter, val any) error
+
+	// rateLimitFn is a closure function that called to stores rate limit key in the backend.
+	rateLimitFn func(set *setter) (bool, error)
+
+	// rateLimitWindowFn is a closure function that called to run the sliding window rate limit in the backend.
+	rateLimitWindowFn func(set *setter, limit int, window time.Duration) (RateLimitResult, error)
+
+	// rateLimitGCRAFn is a closure function that called to run the GCRA rate limit in the backend.
+	rateLimitGCRAFn func(set *setter, rate int, period time.Duration, burst, cost int) (RateLimitResult, error)
 }
 
 func (s *setter) cleanup() {
@@ -51,7 +64,10 @@ func (i *Instance) Set(ctx context.Context, key string) *setter {
 			cancel: cancel,
 			key:    key,
 		},
-		setFn: i.set,
+		setFn:             i.set,
+		rateLimitFn:       i.rateLimitOnce,
+		rateLimitWindowFn: i.rateLimitWindow,
+		rateLimitGCRAFn:   i.rateLimitGCRA,
 	}
 }
 
@@ -62,6 +78,7 @@ func (i *Instance) Set(ctx context.Context, key string) *setter {
 func (s *setter) SetPrefix(prefix string) *setter {
 	if !qore.ValidationIsEmpty(prefix) {
 		s.key = prefix + DefaultKeySeparator + s.key
+		s.prefix = strings.TrimSuffix(prefix+DefaultKeySeparator+s.prefix, DefaultKeySeparator)
 	}
 	return s
 }
@@ -72,6 +89,23 @@ func (s *setter) SetPrefix(prefix string) *setter {
 //	s.SetTTL(10 * time.Second)
 func (s *setter) SetTTL(ttl time.Duration) *setter { s.ttl = ttl; return s }
 
+// SetCodec overrides the instance codec for this call.
+//
+//	s.SetCodec(cache.JSONCodec)
+func (s *setter) SetCodec(codec Codec) *setter { s.codec = codec; return s }
+
+// SetCompression overrides the config Compression for this call, CompressionNone disables it.
+// The value is compressed only when its size reaches the config CompressionThreshold.
+//
+//	s.SetCompression(cache.CompressionZstd)
+func (s *setter) SetCompression(algo string) *setter { s.compression = algo; return s }
+
+// SetVersion overrides the config SchemaVersion for this call, the value is stored in the versioned envelope.
+// Bump it when the stored type changes so the values of the old shape are read as misses.
+//
+//	s.SetVersion(2)
+func (s *setter) SetVersion(version uint32) *setter { s.version = version; return s }
+
 // Put stores the given value in the cache using the configured
 // context, prefix, key, and TTL. This is the final method in the
 // method-chaining sequence. Once executed, the associated cancel
@@ -86,7 +120,7 @@ func (s *setter) Put(value any) (ttl time.Duration, err error) {
 		s = s.SetTTL(DefaultTTL)
 	}
 	ttl = s.ttl
-	_, err = s.setFn(s, value)
+	err = s.setFn(s, value)
 	return
 }
 
@@ -105,7 +139,7 @@ func (s *setter) Put(value any) (ttl time.Duration, err error) {
 func (s *setter) PutForever(value any) (ttl time.Duration, err error) {
 	s.ttl = 0
 	ttl = s.ttl
-	_, err = s.setFn(s, value)
+	err = s.setFn(s, value)
 	return
 }
 
@@ -130,15 +164,5 @@ func (s *setter) RateLimitOnce(period time.Duration) (allowed bool, err error) {
 	if period > 0 {
 		s = s.SetTTL(period)
 	}
-
-	// Borrow client from instance.
-	client, e := s.setFn(s, nil, true)
-	defer s.cleanup()
-	if e != nil {
-		err = e
-		return
-	}
-
-	// Perform SET with NX (only if key doesn't exist) and EX (expire after TTL).
-	return client.SetNX(s.ctx, s.key, 1, s.ttl).Result()
+	return s.rateLimitFn(s)
 }
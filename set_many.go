@@ -0,0 +1,237 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/qoinlyid/qore"
+)
+
+// Item defines a single entry of the multiple keys store operation.
+type Item struct {
+	// Key is the cache key, required.
+	Key string
+
+	// Prefix is the optional key prefix, it is appended after the SetMany prefix (if any).
+	Prefix string
+
+	// Value is the value to be stored.
+	Value any
+
+	// TTL is the time-to-live of the entry, if TTL is <= 0 the SetMany TTL is used.
+	TTL time.Duration
+
+	// Forever stores the entry without an expiration time, TTL is ignored.
+	Forever bool
+}
+
+// PutResult defines the per-key outcome of the multiple keys store operation.
+type PutResult struct {
+	// Key is the item key as given, prefixed by the item prefix (if any).
+	Key string
+
+	// TTL is the time-to-live used to store the item, zero means forever.
+	TTL time.Duration
+
+	// Err is the encode or backend error of the item.
+	Err error
+}
+
+// multiSetter is a method-chaining configuration struct for multiple keys store operations.
+type multiSetter struct {
+	ctx         context.Context
+	cancel      context.CancelFunc
+	prefix      string
+	ttl         time.Duration
+	codec       Codec
+	compression string
+	version     uint32
+
+	// setFn is a closure function that called to stores caches in the backend.
+	setFn func(set *multiSetter, items []Item) ([]PutResult, error)
+}
+
+// SetMany initializes a new multiple keys setter instance for the given prefix (if any).
+// If the provided context is nil, a new background context with
+// a default timeout will be created. The cancel function is stored
+// in the setter and will be called automatically when the final
+// method (e.g., PutMany) completes.
+//
+// This is the entry point for method chaining.
+//
+//	cache.SetMany(ctx, "user").
+//		SetTTL(5 * time.Minute).
+//		PutMany(cache.Item{Key: "1", Value: user1}, cache.Item{Key: "2", Value: user2, TTL: time.Hour})
+func (i *Instance) SetMany(ctx context.Context, prefix ...string) *multiSetter {
+	// Value modifier.
+	var cancel context.CancelFunc
+	if ctx == nil {
+		ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	}
+	set := &multiSetter{
+		ctx:    ctx,
+		cancel: cancel,
+		setFn:  i.setMany,
+	}
+	if len(prefix) > 0 {
+		set.prefix = prefix[0]
+	}
+
+	// Return setter.
+	return set
+}
+
+// SetTTL sets the default time-to-live (TTL) duration for the items without TTL.
+// If TTL is <= 0, the default value will be used is 1 minutes.
+//
+//	s.SetTTL(10 * time.Second)
+func (s *multiSetter) SetTTL(ttl time.Duration) *multiSetter { s.ttl = ttl; return s }
+
+// SetCodec overrides the instance codec for this call.
+//
+//	s.SetCodec(cache.JSONCodec)
+func (s *multiSetter) SetCodec(codec Codec) *multiSetter { s.codec = codec; return s }
+
+// SetCompression overrides the config Compression for this call, CompressionNone disables it.
+//
+//	s.SetCompression(cache.CompressionZstd)
+func (s *multiSetter) SetCompression(algo string) *multiSetter { s.compression = algo; return s }
+
+// SetVersion overrides the config SchemaVersion for this call.
+//
+//	s.SetVersion(2)
+func (s *multiSetter) SetVersion(version uint32) *multiSetter { s.version = version; return s }
+
+// PutMany encodes & stores the given items in a single pipeline of SET commands.
+// This is the final method in the method-chaining sequence.
+//
+// The results are in the same order as the items, so the partial failures are visible per key.
+// The returned error joins all per-key errors, or it is the error of the whole operation.
+//
+//	results, err := s.PutMany(items...)
+//	if err != nil {
+//		for _, res := range results {
+//			if res.Err != nil {
+//				log.Println(res.Key, res.Err)
+//			}
+//		}
+//	}
+func (s *multiSetter) PutMany(items ...Item) ([]PutResult, error) {
+	return s.setFn(s, items)
+}
+
+// setMany helper to store multiple values into the backend store.
+func (i *Instance) setMany(set *multiSetter, items []Item) ([]PutResult, error) {
+	defer func() {
+		if set.cancel != nil {
+			set.cancel()
+		}
+		// Zero out all fields to help GC or prepare for reuse
+		*set = multiSetter{}
+	}()
+
+	// Validate.
+	if err := i.validateStore(); err != nil {
+		return nil, err
+	}
+	defaultTTL := set.ttl
+	if defaultTTL <= 0 {
+		defaultTTL = DefaultTTL
+	}
+
+	// Encode.
+	codec := i.valueCodec(set.codec)
+	results := make([]PutResult, len(items))
+	storeItems := make([]StoreItem, 0, len(items))
+	storeIdxs := make([]int, 0, len(items))
+	for idx, item := range items {
+		key := item.Key
+		if !qore.ValidationIsEmpty(item.Prefix) {
+			key = item.Prefix + DefaultKeySeparator + key
+		}
+		results[idx].Key = key
+		if qore.ValidationIsEmpty(item.Key) {
+			results[idx].Err = ErrEmptyKey
+			continue
+		}
+		switch {
+		case item.Forever:
+			results[idx].TTL = 0
+		case item.TTL > 0:
+			results[idx].TTL = item.TTL
+		default:
+			results[idx].TTL = defaultTTL
+		}
+		encoded, err := codec.Marshal(item.Value)
+		if err != nil {
+			results[idx].Err = opError("set", "", fmt.Errorf("failed to encode value %T: %w", item.Value, err))
+			continue
+		}
+		if !qore.ValidationIsEmpty(set.prefix) {
+			key = set.prefix + DefaultKeySeparator + key
+		}
+		key = i.cfg.Namespace + DefaultKeySeparator + key
+		framed, err := encodeFrame(encoded, i.frameOptions(key, codec, set.version, nil, set.compression))
+		if err != nil {
+			results[idx].Err = opError("set", "", err)
+			continue
+		}
+		storeItems = append(storeItems, StoreItem{
+			Key: key,
+			Val: framed,
+			TTL: results[idx].TTL,
+		})
+		storeIdxs = append(storeIdxs, idx)
+	}
+
+	// Exec, the per-key errors are reported through the results.
+	op := &operation{name: OpSet, keys: make([]string, len(storeItems)), prefix: set.prefix}
+	for n, item := range storeItems {
+		op.keys[n] = strings.TrimPrefix(item.Key, i.cfg.Namespace+DefaultKeySeparator)
+		op.size += len(item.Val)
+	}
+	var errs []error
+	err := i.instrument(set.ctx, op, func(ctx context.Context) error {
+		if batcher, ok := i.store.(Batcher); ok {
+			errs = batcher.MSet(ctx, storeItems)
+		} else {
+			errs = make([]error, len(storeItems))
+			for n, item := range storeItems {
+				errs[n] = i.store.Set(ctx, item.Key, item.Val, item.TTL)
+			}
+		}
+		return errors.Join(errs...)
+	})
+	if len(errs) != len(storeItems) {
+		// The interceptor short-circuits the store call, its error applies to every item.
+		errs = make([]error, len(storeItems))
+		for n := range errs {
+			errs[n] = err
+		}
+	}
+	written := make([]string, 0, len(storeItems))
+	for n, item := range storeItems {
+		if errs[n] != nil {
+			results[storeIdxs[n]].Err = opError("set", "", errs[n])
+			if i.local != nil {
+				i.local.del(item.Key)
+			}
+			continue
+		}
+		i.storeLocal(item.Key, item.Val, item.TTL)
+		written = append(written, item.Key)
+	}
+	i.publishInvalidation(set.ctx, written...)
+
+	// Join per-key errors.
+	var joined []error
+	for _, res := range results {
+		if res.Err != nil {
+			joined = append(joined, fmt.Errorf("key %s: %w", res.Key, res.Err))
+		}
+	}
+	return results, errors.Join(joined...)
+}
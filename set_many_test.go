@@ -0,0 +1,105 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetManyPutMany(t *testing.T) {
+	i := newMemoryTest(t)
+	results, err := i.SetMany(t.Context(), testPrefix).SetTTL(time.Hour).PutMany(
+		Item{Key: "a", Value: "A"},
+		Item{Key: "b", Value: 2, TTL: time.Minute},
+		Item{Key: "c", Prefix: "sub", Value: true, Forever: true},
+	)
+	assert.NoError(t, err, "PutMany must be no error")
+	assert.Equal(t, []PutResult{
+		{Key: "a", TTL: time.Hour},
+		{Key: "b", TTL: time.Minute},
+		{Key: "sub" + DefaultKeySeparator + "c", TTL: 0},
+	}, results, "Results must be in the same order as the items")
+
+	var a string
+	i.Get(t.Context(), "a", testPrefix).Pull(&a)
+	assert.Equal(t, "A", a, "Item a must be stored")
+	var c bool
+	i.Get(t.Context(), "c", testPrefix+DefaultKeySeparator+"sub").Pull(&c)
+	assert.True(t, c, "Item c must be stored under the item prefix")
+}
+
+func TestSetManyPartialFailure(t *testing.T) {
+	i := newMemoryTest(t)
+	results, err := i.SetMany(t.Context()).PutMany(
+		Item{Key: "ok", Value: "value"},
+		Item{Key: "", Value: "value"},
+		Item{Key: "bad", Value: make(chan int)},
+	)
+	assert.Error(t, err, "PutMany must return joined per-key errors")
+	assert.NoError(t, results[0].Err, "Valid item must be stored")
+	assert.ErrorIs(t, results[1].Err, ErrEmptyKey, "Empty key must be reported")
+	assert.Error(t, results[2].Err, "Unencodable value must be reported")
+	assert.True(t, i.Has(t.Context(), "ok"), "Valid item must exist")
+	assert.False(t, i.Has(t.Context(), "bad"), "Failed item must not exist")
+}
+
+func TestSetManyIntercepted(t *testing.T) {
+	i := newMemoryTest(t).Use(func(ctx context.Context, op Op, next Handler) error {
+		return ErrBackendUnavailable
+	})
+	results, err := i.SetMany(t.Context()).PutMany(
+		Item{Key: "a", Value: "value"},
+		Item{Key: "b", Value: "value"},
+	)
+	assert.ErrorIs(t, err, ErrBackendUnavailable, "PutMany must return the interceptor error")
+	for _, res := range results {
+		assert.ErrorIs(t, res.Err, ErrBackendUnavailable, fmt.Sprintf("Item %s must carry the interceptor error", res.Key))
+	}
+}
+
+func TestSetManyInvalidation(t *testing.T) {
+	a, b := newInvalidationTest(t)
+	a.Set(t.Context(), "k").Put("v1")
+	var val string
+	b.Get(t.Context(), "k").Pull(&val)
+
+	a.SetMany(t.Context()).PutMany(Item{Key: "k", Value: "v2"})
+	b.Get(t.Context(), "k").Pull(&val)
+	assert.Equal(t, "v2", val, "Local copy of b must be evicted after PutMany")
+}
+
+func TestTypedSetMany(t *testing.T) {
+	i := newMemoryTest(t)
+	users := For[typedUser](i, testPrefix)
+	err := users.SetMany(t.Context(), map[string]typedUser{"1": {ID: 1}, "2": {ID: 2}}, time.Minute)
+	assert.NoError(t, err, "SetMany must be no error")
+
+	vals, misses, err := users.GetMany(t.Context(), []string{"1", "2"})
+	assert.NoError(t, err, "GetMany must be no error")
+	assert.Empty(t, misses, "Must be no missing key")
+	assert.Len(t, vals, 2, "Values must contain all stored items")
+}
+
+func TestSetManyRedis(t *testing.T) {
+	i := newRedisTest(t)
+
+	items := make([]Item, 50)
+	keys := make([]string, len(items))
+	for n := range items {
+		keys[n] = fmt.Sprint("SetMany", n)
+		items[n] = Item{Key: keys[n], Value: n, TTL: time.Duration(n+1) * time.Second}
+	}
+	_, err := i.SetMany(t.Context(), testPrefix).PutMany(items...)
+	assert.NoError(t, err, "PutMany must be no error")
+
+	var vals []int
+	res, err := i.GetMany(t.Context(), keys, testPrefix).Pull(&vals)
+	assert.NoError(t, err, "GetMany must be no error")
+	assert.Empty(t, res.Misses, "Must be no missing key")
+	for n := range keys {
+		assert.Equal(t, n, vals[n], "Values must be equal to the stored values")
+	}
+}
@@ -0,0 +1,110 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Store defines cache backend contract that used by the Instance.
+//
+// All keys received by the Store are already prefixed by the namespace & the prefix (if any),
+// so the implementation must treat them as opaque strings.
+type Store interface {
+	// Get returns the raw value of the key, ErrCacheMiss (or redis.Nil) is returned when the key does not exist.
+	Get(ctx context.Context, key string) ([]byte, error)
+
+	// Set stores the raw value of the key, ttl <= 0 means the key never expires.
+	Set(ctx context.Context, key string, val []byte, ttl time.Duration) error
+
+	// SetNX stores the raw value only if the key does not exist yet,
+	// it returns true if the value was stored.
+	SetNX(ctx context.Context, key string, val []byte, ttl time.Duration) (bool, error)
+
+	// Del deletes the keys and returns the number of deleted keys.
+	Del(ctx context.Context, keys ...string) (int64, error)
+
+	// Exists returns the number of the given keys that exist.
+	Exists(ctx context.Context, keys ...string) (int64, error)
+
+	// Expire sets the time-to-live of the key, it returns false if the key does not exist.
+	Expire(ctx context.Context, key string, ttl time.Duration) (bool, error)
+
+	// Scan returns all keys that match the glob-style pattern.
+	Scan(ctx context.Context, match string) ([]string, error)
+
+	// Ping checks the backend availability.
+	Ping(ctx context.Context) (string, error)
+
+	// Close releases the backend resources.
+	Close() error
+}
+
+// Notifier is an optional interface implemented by Store that able to broadcast messages between
+// instances, it is used to keep the L1 tier of the instances coherent.
+type Notifier interface {
+	// Publish sends the payload to the channel subscribers.
+	Publish(ctx context.Context, channel string, payload []byte) error
+
+	// Subscribe blocks & calls onMessage for every payload received from the channel until ctx is done.
+	// onReset is called every time the subscription is (re)established, since the messages published
+	// while the subscription is down are lost.
+	Subscribe(ctx context.Context, channel string, onMessage func(payload []byte), onReset func()) error
+}
+
+// StoreItem defines a single raw value to be stored by the Batcher.
+type StoreItem struct {
+	Key string
+	Val []byte
+	TTL time.Duration
+}
+
+// Batcher is an optional interface implemented by Store that able to read & write multiple keys in a single
+// round trip, the Instance falls back to the sequential Get & Set when the Store does not implement it.
+type Batcher interface {
+	// MGet returns the raw values of the keys in the same order, nil value means the key does not exist.
+	MGet(ctx context.Context, keys ...string) ([][]byte, error)
+
+	// MSet stores the raw values of the items & returns the per-item error in the same order.
+	MSet(ctx context.Context, items []StoreItem) []error
+}
+
+// RateLimiter is an optional interface implemented by Store that able to run the rate limiters atomically,
+// the rate limiters return ErrRateLimitNotSupported when the Store does not implement it.
+type RateLimiter interface {
+	// SlidingWindow increments the counter of the current fixed window only if the count of the current window
+	// plus the count of the previous window weighted by its overlap with the sliding window is below the limit.
+	// The windows are multiples of window on the store clock, the counter of a window is the key suffixed by
+	// the window index & it expires after two windows. It returns whether the call is allowed, both counters &
+	// the time elapsed since the current window started.
+	SlidingWindow(
+		ctx context.Context, key string, limit int64, window time.Duration,
+	) (allowed bool, cur, prev int64, elapsed time.Duration, err error)
+
+	// GCRA adds increment to the theoretical arrival time of the key (at least now) only if the result minus
+	// tolerance is not after now, now is taken from the store clock & the times are Unix microseconds.
+	// It returns whether the call is allowed, the theoretical arrival time after the call & now, the key
+	// expires when the theoretical arrival time passes.
+	GCRA(ctx context.Context, key string, increment, tolerance int64) (allowed bool, tat, now int64, err error)
+}
+
+// Leaser is an optional interface implemented by Store that able to hold the semaphore leases atomically,
+// the semaphore returns ErrSemaphoreNotSupported when the Store does not implement it. A lease is held until
+// its expiry, now is taken from the store clock.
+type Leaser interface {
+	// AcquireLease removes the expired leases of the key, then adds the lease id expiring after ttl
+	// only if the key holds less than limit leases. It returns true if the lease was added.
+	AcquireLease(ctx context.Context, key, id string, limit int64, ttl time.Duration) (bool, error)
+
+	// ExtendLease moves the expiry of the lease id to ttl from now only if it is still held.
+	ExtendLease(ctx context.Context, key, id string, ttl time.Duration) (bool, error)
+
+	// ReleaseLease removes the lease id, it returns true if the lease was still held.
+	ReleaseLease(ctx context.Context, key, id string) (bool, error)
+}
+
+// Locker is an optional interface implemented by Store that able to release a lock atomically,
+// the Instance falls back to the Get & Del when the Store does not implement it.
+type Locker interface {
+	// Unlock deletes the key only if its value equals to the token, it returns true if the key was deleted.
+	Unlock(ctx context.Context, key string, token []byte) (bool, error)
+}
@@ -0,0 +1,553 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// memoryEvictionSamples defines how many entries are sampled to pick the eviction victim,
+// the same approximation used by redis maxmemory policy.
+const memoryEvictionSamples = 5
+
+// memoryEntry is a single in-memory cache entry.
+type memoryEntry struct {
+	val      []byte
+	expireAt int64 // Unix nano, zero means never expires.
+	access   int64 // Unix nano of the last access.
+	hits     int64 // Access frequency, used by LFU policy.
+}
+
+func (e *memoryEntry) expired(now int64) bool {
+	return e.expireAt > 0 && e.expireAt <= now
+}
+
+// memoryStore is the in-process Store implementation, useful for unit tests & single-node deployments.
+type memoryStore struct {
+	mu         sync.Mutex
+	items      map[string]*memoryEntry
+	maxEntries int
+	lfu        bool
+
+	subMu       sync.RWMutex
+	subscribers map[string]map[*memorySubscriber]struct{}
+
+	stop      chan struct{}
+	closeOnce sync.Once
+}
+
+// memorySubscriber is a single in-process channel subscriber.
+type memorySubscriber struct {
+	onMessage func(payload []byte)
+}
+
+// Compile time check memoryStore implements Store, Notifier, Batcher, Locker, RateLimiter & Leaser.
+var (
+	_ Store       = (*memoryStore)(nil)
+	_ Notifier    = (*memoryStore)(nil)
+	_ Batcher     = (*memoryStore)(nil)
+	_ Locker      = (*memoryStore)(nil)
+	_ RateLimiter = (*memoryStore)(nil)
+	_ Leaser      = (*memoryStore)(nil)
+)
+
+// newMemoryStore creates in-memory store.
+// maxEntries <= 0 means unlimited entries, cleanupInterval > 0 starts the expired entries janitor,
+// policy is the eviction policy either LocalPolicyLRU or LocalPolicyLFU.
+func newMemoryStore(maxEntries int, cleanupInterval time.Duration, policy string) *memoryStore {
+	store := &memoryStore{
+		items:       make(map[string]*memoryEntry),
+		maxEntries:  maxEntries,
+		lfu:         policy == LocalPolicyLFU,
+		subscribers: make(map[string]map[*memorySubscriber]struct{}),
+		stop:        make(chan struct{}),
+	}
+	if cleanupInterval > 0 {
+		go store.janitor(cleanupInterval)
+	}
+	return store
+}
+
+// janitor periodically removes the expired entries.
+func (s *memoryStore) janitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			now := time.Now().UnixNano()
+			s.mu.Lock()
+			for key, entry := range s.items {
+				if entry.expired(now) {
+					delete(s.items, key)
+				}
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+// lookup returns alive entry of the key, the expired entry is removed. Caller must hold the lock.
+func (s *memoryStore) lookup(key string, now int64) (*memoryEntry, bool) {
+	entry, ok := s.items[key]
+	if !ok {
+		return nil, false
+	}
+	if entry.expired(now) {
+		delete(s.items, key)
+		return nil, false
+	}
+	return entry, true
+}
+
+// store puts the entry of the key and evicts when the store is full. Caller must hold the lock.
+func (s *memoryStore) store(key string, val []byte, ttl time.Duration, now int64) {
+	entry := &memoryEntry{val: bytes.Clone(val), access: now}
+	if entry.val == nil {
+		entry.val = []byte{}
+	}
+	if ttl > 0 {
+		entry.expireAt = now + int64(ttl)
+	}
+	if prev, ok := s.items[key]; ok {
+		entry.hits = prev.hits
+	} else {
+		s.evict(now)
+	}
+	s.items[key] = entry
+}
+
+// evict removes the least recently (or frequently for LFU policy) used entry among the sampled entries
+// while the store is full. Caller must hold the lock.
+func (s *memoryStore) evict(now int64) {
+	for s.maxEntries > 0 && len(s.items) >= s.maxEntries {
+		var (
+			victim  string
+			least   *memoryEntry
+			sampled int
+		)
+		for key, entry := range s.items {
+			if entry.expired(now) {
+				victim = key
+				break
+			}
+			if least == nil || s.less(entry, least) {
+				victim, least = key, entry
+			}
+			sampled++
+			if sampled >= memoryEvictionSamples {
+				break
+			}
+		}
+		delete(s.items, victim)
+	}
+}
+
+// less reports whether entry a is a better eviction victim than entry b.
+func (s *memoryStore) less(a, b *memoryEntry) bool {
+	if s.lfu && a.hits != b.hits {
+		return a.hits < b.hits
+	}
+	return a.access < b.access
+}
+
+// Get returns the raw value of the key, ErrCacheMiss is returned when the key does not exist.
+func (s *memoryStore) Get(_ context.Context, key string) ([]byte, error) {
+	now := time.Now().UnixNano()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.lookup(key, now)
+	if !ok {
+		return nil, ErrCacheMiss
+	}
+	entry.access = now
+	entry.hits++
+	return bytes.Clone(entry.val), nil
+}
+
+// Set stores the raw value of the key.
+func (s *memoryStore) Set(_ context.Context, key string, val []byte, ttl time.Duration) error {
+	now := time.Now().UnixNano()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.store(key, val, ttl, now)
+	return nil
+}
+
+// SetNX stores the raw value only if the key does not exist yet.
+func (s *memoryStore) SetNX(_ context.Context, key string, val []byte, ttl time.Duration) (bool, error) {
+	now := time.Now().UnixNano()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.lookup(key, now); ok {
+		return false, nil
+	}
+	s.store(key, val, ttl, now)
+	return true, nil
+}
+
+// Unlock deletes the lock key only if its value equals to the token.
+func (s *memoryStore) Unlock(_ context.Context, key string, token []byte) (bool, error) {
+	now := time.Now().UnixNano()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.lookup(key, now)
+	if !ok || !bytes.Equal(entry.val, token) {
+		return false, nil
+	}
+	delete(s.items, key)
+	return true, nil
+}
+
+// SlidingWindow increments the current window counter only if the weighted count is below the limit.
+func (s *memoryStore) SlidingWindow(
+	_ context.Context, key string, limit int64, window time.Duration,
+) (bool, int64, int64, time.Duration, error) {
+	now := time.Now().UnixNano()
+	idx := now / int64(window)
+	elapsed := time.Duration(now % int64(window))
+	curKey := key + DefaultKeySeparator + strconv.FormatInt(idx, 10)
+	prevKey := key + DefaultKeySeparator + strconv.FormatInt(idx-1, 10)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cur, prev := s.counter(curKey, now), s.counter(prevKey, now)
+	if float64(prev)*float64(window-elapsed)/float64(window)+float64(cur+1) > float64(limit) {
+		return false, cur, prev, elapsed, nil
+	}
+	cur++
+	s.store(curKey, strconv.AppendInt(nil, cur, 10), 2*window, now)
+	return true, cur, prev, elapsed, nil
+}
+
+// GCRA moves the theoretical arrival time forward only if the call is within the burst tolerance.
+func (s *memoryStore) GCRA(_ context.Context, key string, increment, tolerance int64) (bool, int64, int64, error) {
+	t := time.Now()
+	now, nowNano := t.UnixMicro(), t.UnixNano()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tat := max(s.counter(key, nowNano), now)
+	newTat := tat + increment
+	if newTat-tolerance > now {
+		return false, tat, now, nil
+	}
+	s.store(key, strconv.AppendInt(nil, newTat, 10), time.Duration(newTat-now)*time.Microsecond, nowNano)
+	return true, newTat, now, nil
+}
+
+// AcquireLease reclaims the expired leases then adds the lease only if there is a permit left.
+func (s *memoryStore) AcquireLease(_ context.Context, key, id string, limit int64, ttl time.Duration) (bool, error) {
+	t := time.Now()
+	now, nowNano := t.UnixMilli(), t.UnixNano()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	leases := s.leases(key, now, nowNano)
+	if int64(len(leases)) >= limit {
+		return false, nil
+	}
+	leases[id] = now + ttl.Milliseconds()
+	s.storeLeases(key, leases, now, nowNano)
+	return true, nil
+}
+
+// ExtendLease moves the lease expiry only if it is still held.
+func (s *memoryStore) ExtendLease(_ context.Context, key, id string, ttl time.Duration) (bool, error) {
+	t := time.Now()
+	now, nowNano := t.UnixMilli(), t.UnixNano()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	leases := s.leases(key, now, nowNano)
+	if _, ok := leases[id]; !ok {
+		return false, nil
+	}
+	leases[id] = now + ttl.Milliseconds()
+	s.storeLeases(key, leases, now, nowNano)
+	return true, nil
+}
+
+// ReleaseLease removes the lease & tells whether it was still held.
+func (s *memoryStore) ReleaseLease(_ context.Context, key, id string) (bool, error) {
+	t := time.Now()
+	now, nowNano := t.UnixMilli(), t.UnixNano()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	leases := s.leases(key, now, nowNano)
+	_, ok := leases[id]
+	delete(leases, id)
+	s.storeLeases(key, leases, now, nowNano)
+	return ok, nil
+}
+
+// leases returns the leases of the key held at now (Unix milliseconds). Caller must hold the lock.
+func (s *memoryStore) leases(key string, now, nowNano int64) map[string]int64 {
+	leases := make(map[string]int64)
+	if entry, ok := s.lookup(key, nowNano); ok {
+		msgpack.Unmarshal(entry.val, &leases)
+	}
+	for id, expireAt := range leases {
+		if expireAt <= now {
+			delete(leases, id)
+		}
+	}
+	return leases
+}
+
+// storeLeases stores the leases of the key expiring with its last lease. Caller must hold the lock.
+func (s *memoryStore) storeLeases(key string, leases map[string]int64, now, nowNano int64) {
+	if len(leases) == 0 {
+		delete(s.items, key)
+		return
+	}
+	var last int64
+	for _, expireAt := range leases {
+		last = max(last, expireAt)
+	}
+	b, _ := msgpack.Marshal(leases)
+	s.store(key, b, time.Duration(last-now)*time.Millisecond, nowNano)
+}
+
+// counter returns the integer value of the key, zero if it does not exist. Caller must hold the lock.
+func (s *memoryStore) counter(key string, now int64) int64 {
+	entry, ok := s.lookup(key, now)
+	if !ok {
+		return 0
+	}
+	n, _ := strconv.ParseInt(string(entry.val), 10, 64)
+	return n
+}
+
+// Del deletes the keys.
+func (s *memoryStore) Del(_ context.Context, keys ...string) (int64, error) {
+	now := time.Now().UnixNano()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var count int64
+	for _, key := range keys {
+		if _, ok := s.lookup(key, now); ok {
+			delete(s.items, key)
+			count++
+		}
+	}
+	return count, nil
+}
+
+// Exists returns the number of the given keys that exist.
+func (s *memoryStore) Exists(_ context.Context, keys ...string) (int64, error) {
+	now := time.Now().UnixNano()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var count int64
+	for _, key := range keys {
+		if _, ok := s.lookup(key, now); ok {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// Expire sets the time-to-live of the key, ttl <= 0 removes the expiration.
+func (s *memoryStore) Expire(_ context.Context, key string, ttl time.Duration) (bool, error) {
+	now := time.Now().UnixNano()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.lookup(key, now)
+	if !ok {
+		return false, nil
+	}
+	entry.expireAt = 0
+	if ttl > 0 {
+		entry.expireAt = now + int64(ttl)
+	}
+	return true, nil
+}
+
+// Scan returns all keys that match the glob-style pattern.
+func (s *memoryStore) Scan(_ context.Context, match string) ([]string, error) {
+	now := time.Now().UnixNano()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var keys []string
+	for key, entry := range s.items {
+		if entry.expired(now) {
+			delete(s.items, key)
+			continue
+		}
+		if globMatch(match, key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// MGet returns the raw values of the keys, nil value means the key does not exist.
+func (s *memoryStore) MGet(_ context.Context, keys ...string) ([][]byte, error) {
+	now := time.Now().UnixNano()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	vals := make([][]byte, len(keys))
+	for idx, key := range keys {
+		if entry, ok := s.lookup(key, now); ok {
+			entry.access = now
+			entry.hits++
+			vals[idx] = bytes.Clone(entry.val)
+		}
+	}
+	return vals, nil
+}
+
+// MSet stores the raw values of the items.
+func (s *memoryStore) MSet(_ context.Context, items []StoreItem) []error {
+	now := time.Now().UnixNano()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, item := range items {
+		s.store(item.Key, item.Val, item.TTL, now)
+	}
+	return make([]error, len(items))
+}
+
+// Ping always returns PONG.
+func (s *memoryStore) Ping(_ context.Context) (string, error) {
+	return "PONG", nil
+}
+
+// Publish sends the payload to the in-process channel subscribers.
+func (s *memoryStore) Publish(_ context.Context, channel string, payload []byte) error {
+	s.subMu.RLock()
+	defer s.subMu.RUnlock()
+	for sub := range s.subscribers[channel] {
+		sub.onMessage(append([]byte(nil), payload...))
+	}
+	return nil
+}
+
+// Subscribe registers in-process channel subscriber & blocks until ctx is done or the store is closed.
+func (s *memoryStore) Subscribe(
+	ctx context.Context,
+	channel string,
+	onMessage func(payload []byte),
+	onReset func(),
+) error {
+	sub := &memorySubscriber{onMessage: onMessage}
+	s.subMu.Lock()
+	if s.subscribers[channel] == nil {
+		s.subscribers[channel] = make(map[*memorySubscriber]struct{})
+	}
+	s.subscribers[channel][sub] = struct{}{}
+	s.subMu.Unlock()
+	onReset()
+
+	select {
+	case <-ctx.Done():
+	case <-s.stop:
+	}
+	s.subMu.Lock()
+	delete(s.subscribers[channel], sub)
+	s.subMu.Unlock()
+	return nil
+}
+
+// Close stops the janitor & releases all entries.
+func (s *memoryStore) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.stop)
+		s.mu.Lock()
+		s.items = make(map[string]*memoryEntry)
+		s.mu.Unlock()
+	})
+	return nil
+}
+
+// globMatch reports whether s matches the redis glob-style pattern.
+// Supported syntax: `*`, `?`, `[abc]`, `[^abc]`, `[a-z]` and `\` to escape.
+func globMatch(pattern, s string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 0 && pattern[0] == '*' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 0 {
+				return true
+			}
+			for i := 0; i <= len(s); i++ {
+				if globMatch(pattern, s[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(s) == 0 {
+				return false
+			}
+		case '[':
+			if len(s) == 0 {
+				return false
+			}
+			end := 1
+			for end < len(pattern) && pattern[end] != ']' {
+				if pattern[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(pattern) {
+				// Unterminated class, treat '[' as literal.
+				if s[0] != '[' {
+					return false
+				}
+				break
+			}
+			class := pattern[1:end]
+			negate := len(class) > 0 && class[0] == '^'
+			if negate {
+				class = class[1:]
+			}
+			if matchClass(class, s[0]) == negate {
+				return false
+			}
+			pattern = pattern[end:]
+		case '\\':
+			if len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+			fallthrough
+		default:
+			if len(s) == 0 || pattern[0] != s[0] {
+				return false
+			}
+		}
+		pattern = pattern[1:]
+		s = s[1:]
+	}
+	return len(s) == 0
+}
+
+// matchClass reports whether c is a member of the glob character class.
+func matchClass(class string, c byte) bool {
+	for i := 0; i < len(class); i++ {
+		lo := class[i]
+		if lo == '\\' && i+1 < len(class) {
+			i++
+			lo = class[i]
+		}
+		if i+2 < len(class) && class[i+1] == '-' {
+			hi := class[i+2]
+			i += 2
+			if lo <= c && c <= hi {
+				return true
+			}
+			continue
+		}
+		if lo == c {
+			return true
+		}
+	}
+	return false
+}
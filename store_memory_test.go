@@ -0,0 +1,181 @@
+package cache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/qoinlyid/qore"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+// newMemoryTest returns opened instance using memory driver.
+func newMemoryTest(t *testing.T) *Instance {
+	t.Helper()
+	t.Setenv(qore.CONFIG_USED_KEY, "OS")
+	t.Setenv("CACHE_DRIVER", DriverMemory)
+	i := New()
+	if err := i.Open(); err != nil {
+		t.Fatalf("Open memory driver must be no error: %s", err)
+	}
+	t.Cleanup(func() { i.Close() })
+	return i
+}
+
+func TestMemoryDriverFromConfig(t *testing.T) {
+	i := newMemoryTest(t)
+	assert.Equal(t, DriverMemory, i.cfg.Driver, fmt.Sprintf("Driver should be %s", DriverMemory))
+	_, ok := i.store.(*memoryStore)
+	assert.True(t, ok, "Store must be memory store")
+	_, err := i.Client()
+	assert.ErrorIs(t, err, ErrClientNil, "Client must be error for memory driver")
+}
+
+func TestMemoryStoreTTL(t *testing.T) {
+	store := newMemoryStore(0, 0, LocalPolicyLRU)
+	defer store.Close()
+
+	err := store.Set(t.Context(), "key", []byte(testValue), 20*time.Millisecond)
+	assert.NoError(t, err, "Set must be no error")
+	b, err := store.Get(t.Context(), "key")
+	assert.NoError(t, err, "Get must be no error before expired")
+	assert.Equal(t, testValue, string(b), "Value must be equal to the stored value")
+
+	time.Sleep(30 * time.Millisecond)
+	_, err = store.Get(t.Context(), "key")
+	assert.ErrorIs(t, err, ErrCacheMiss, "Get expired key must be ErrCacheMiss")
+}
+
+func TestMemoryStoreExpire(t *testing.T) {
+	store := newMemoryStore(0, 0, LocalPolicyLRU)
+	defer store.Close()
+
+	store.Set(t.Context(), "key", []byte(testValue), 0)
+	ok, err := store.Expire(t.Context(), "key", 20*time.Millisecond)
+	assert.NoError(t, err, "Expire must be no error")
+	assert.True(t, ok, "Expire existing key must be true")
+	ok, _ = store.Expire(t.Context(), "missing", time.Minute)
+	assert.False(t, ok, "Expire missing key must be false")
+
+	time.Sleep(30 * time.Millisecond)
+	count, _ := store.Exists(t.Context(), "key")
+	assert.Equal(t, int64(0), count, "Expired key must not exist")
+}
+
+func TestMemoryStoreSetNX(t *testing.T) {
+	store := newMemoryStore(0, 0, LocalPolicyLRU)
+	defer store.Close()
+
+	ok, err := store.SetNX(t.Context(), "key", []byte("1"), 20*time.Millisecond)
+	assert.NoError(t, err, "SetNX must be no error")
+	assert.True(t, ok, "SetNX must be stored for the first time")
+	ok, _ = store.SetNX(t.Context(), "key", []byte("1"), 20*time.Millisecond)
+	assert.False(t, ok, "SetNX must not be stored while the key exists")
+
+	time.Sleep(30 * time.Millisecond)
+	ok, _ = store.SetNX(t.Context(), "key", []byte("1"), time.Minute)
+	assert.True(t, ok, "SetNX must be stored after the key expired")
+}
+
+func TestMemoryStoreScan(t *testing.T) {
+	store := newMemoryStore(0, 0, LocalPolicyLRU)
+	defer store.Close()
+
+	for _, key := range []string{"ns:user:1", "ns:user:2", "ns:session:1", "ns:user"} {
+		store.Set(t.Context(), key, []byte(testValue), 0)
+	}
+	keys, err := store.Scan(t.Context(), "ns:user:*")
+	assert.NoError(t, err, "Scan must be no error")
+	assert.ElementsMatch(t, []string{"ns:user:1", "ns:user:2"}, keys, "Scan must only return matched keys")
+}
+
+func TestMemoryStoreEviction(t *testing.T) {
+	store := newMemoryStore(3, 0, LocalPolicyLRU)
+	defer store.Close()
+
+	for n := range 10 {
+		store.Set(t.Context(), fmt.Sprintf("key-%d", n), []byte(testValue), 0)
+	}
+	count, _ := store.Exists(t.Context(), "key-7", "key-8", "key-9")
+	assert.LessOrEqual(t, len(store.items), 3, "Entries must not exceed max entries")
+	assert.Greater(t, count, int64(0), "Latest entries must be kept")
+}
+
+func TestMemoryStoreEvictionLFU(t *testing.T) {
+	store := newMemoryStore(memoryEvictionSamples, 0, LocalPolicyLFU)
+	defer store.Close()
+
+	store.Set(t.Context(), "hot", []byte(testValue), 0)
+	for range 10 {
+		store.Get(t.Context(), "hot")
+	}
+	for n := range 20 {
+		store.Set(t.Context(), fmt.Sprintf("key-%d", n), []byte(testValue), 0)
+	}
+	count, _ := store.Exists(t.Context(), "hot")
+	assert.Equal(t, int64(1), count, "Frequently used entry must be kept")
+}
+
+func TestGlobMatch(t *testing.T) {
+	cases := []struct {
+		pattern string
+		s       string
+		match   bool
+	}{
+		{"*", "anything", true},
+		{"ns:*", "ns:key", true},
+		{"ns:*", "other:key", false},
+		{"h?llo", "hello", true},
+		{"h?llo", "hllo", false},
+		{"h[ae]llo", "hallo", true},
+		{"h[^e]llo", "hello", false},
+		{"h[a-c]llo", "hbllo", true},
+		{`h\*llo`, "h*llo", true},
+		{`h\*llo`, "hello", false},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.match, globMatch(c.pattern, c.s), fmt.Sprintf("globMatch(%q, %q)", c.pattern, c.s))
+	}
+}
+
+func TestMemoryDriverSurface(t *testing.T) {
+	i := newMemoryTest(t)
+
+	// Set & Get.
+	_, err := i.Set(t.Context(), testKey).SetPrefix(testPrefix).Put(testValue)
+	assert.NoError(t, err, "Put must be no error")
+	var val string
+	err = i.Get(t.Context(), testKey, testPrefix).Pull(&val)
+	assert.NoError(t, err, "Pull must be no error")
+	assert.Equal(t, testValue, val, fmt.Sprintf("Value should be %s", testValue))
+	assert.True(t, i.Has(t.Context(), testKey, testPrefix), "Has must be true")
+
+	// Remember.
+	var remembered int
+	err = i.Get(t.Context(), testKeyRemember, testPrefix).Remember(&remembered, func() (bool, any, error) {
+		return false, testValueRemember, nil
+	})
+	assert.NoError(t, err, "Remember must be no error")
+	assert.Equal(t, testValueRemember, remembered, fmt.Sprintf("Value should be %d", testValueRemember))
+
+	// GetAllKeys.
+	keys, err := i.GetAllKeys(t.Context(), testPrefix)
+	assert.NoError(t, err, "GetAllKeys must be no error")
+	assert.Len(t, keys, 2, "GetAllKeys must return 2 keys")
+
+	// RateLimitOnce.
+	allowed, err := i.Set(t.Context(), "LimitKey").RateLimitOnce(time.Minute)
+	assert.NoError(t, err, "RateLimitOnce must be no error")
+	assert.True(t, allowed, "RateLimitOnce must be allowed for the first time")
+	allowed, _ = i.Set(t.Context(), "LimitKey").RateLimitOnce(time.Minute)
+	assert.False(t, allowed, "RateLimitOnce must be blocked for the second time")
+
+	// Delete.
+	count, err := i.Delete(t.Context(), testKey, testPrefix).Perform()
+	assert.NoError(t, err, "Perform must be no error")
+	assert.Equal(t, int64(1), count, "Deleted count must be 1")
+	err = i.Get(t.Context(), testKey, testPrefix).Pull(&val)
+	assert.ErrorIs(t, err, ErrCacheMiss, "Pull deleted key must be ErrCacheMiss")
+	assert.ErrorIs(t, err, redis.Nil, "Pull deleted key must still match redis.Nil")
+}
@@ -0,0 +1,640 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/qoinlyid/qore"
+	"github.com/redis/go-redis/v9"
+)
+
+// redisStore is the Store implementation backed by redis standalone, cluster or sentinel.
+type redisStore struct {
+	client     redis.UniversalClient
+	clustering bool
+
+	// newClient creates an extra non-cluster client with the same options, nil for cluster mode.
+	newClient func(protocol int, onConnect func(ctx context.Context, cn *redis.Conn) error) *redis.Client
+
+	// trackedMu guards tracked, the client-side caching reads client.
+	trackedMu sync.RWMutex
+	tracked   *redis.Client
+
+	// logHookOnce installs the logging hook once, the instance may be opened more than once.
+	logHookOnce sync.Once
+}
+
+// Compile time check redisStore implements Store, Notifier, Batcher, Locker, RateLimiter & Leaser.
+var (
+	_ Store       = (*redisStore)(nil)
+	_ Notifier    = (*redisStore)(nil)
+	_ Batcher     = (*redisStore)(nil)
+	_ Locker      = (*redisStore)(nil)
+	_ RateLimiter = (*redisStore)(nil)
+	_ Leaser      = (*redisStore)(nil)
+)
+
+// redisSubscribeHealthCheck defines how long the subscription is idle before it is pinged.
+const redisSubscribeHealthCheck = 30 * time.Second
+
+// redisSubscribeBackoff defines delay before receiving again after the subscription is dropped.
+const redisSubscribeBackoff = time.Second
+
+// redisInvalidateChannel is the channel used by redis to push client-side caching invalidation.
+const redisInvalidateChannel = "__redis__:invalidate"
+
+// redisUnlockScript deletes the lock key only if it is still owned by the token.
+var redisUnlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// redisSlidingWindowScript increments the current window counter only if the weighted count is below the limit,
+// the windows (microseconds) are derived from the redis clock so the clocks of the instances do not matter.
+// The window counters are in the hash slot of the key.
+var redisSlidingWindowScript = redis.NewScript(`
+local t = redis.call("TIME")
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+local window = tonumber(ARGV[2])
+local idx = math.floor(now / window)
+local elapsed = now - idx * window
+local curKey = KEYS[1] .. ":" .. string.format("%d", idx)
+local prevKey = KEYS[1] .. ":" .. string.format("%d", idx - 1)
+local cur = tonumber(redis.call("GET", curKey) or "0")
+local prev = tonumber(redis.call("GET", prevKey) or "0")
+local allowed = 0
+if prev * (window - elapsed) / window + cur + 1 <= tonumber(ARGV[1]) then
+	cur = redis.call("INCR", curKey)
+	redis.call("PEXPIRE", curKey, math.ceil(2 * window / 1000))
+	allowed = 1
+end
+return {allowed, cur, prev, elapsed}
+`)
+
+// redisGCRAScript moves the theoretical arrival time forward only if the call is within the burst tolerance,
+// now (microseconds) is taken from the redis clock so the clocks of the instances do not matter.
+var redisGCRAScript = redis.NewScript(`
+local t = redis.call("TIME")
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+local tat = math.max(tonumber(redis.call("GET", KEYS[1]) or "0"), now)
+local newTat = tat + tonumber(ARGV[1])
+if newTat - tonumber(ARGV[2]) > now then
+	return {0, tat, now}
+end
+redis.call("SET", KEYS[1], string.format("%d", newTat), "PX", math.ceil((newTat - now) / 1000))
+return {1, newTat, now}
+`)
+
+// redisAcquireLeaseScript reclaims the expired leases then adds the lease only if there is a permit left,
+// the key expires with its last lease. The lease expiry (milliseconds) is taken from the redis clock.
+var redisAcquireLeaseScript = redis.NewScript(`
+local t = redis.call("TIME")
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", now)
+if redis.call("ZCARD", KEYS[1]) >= tonumber(ARGV[1]) then
+	return 0
+end
+redis.call("ZADD", KEYS[1], now + tonumber(ARGV[2]), ARGV[3])
+local last = redis.call("ZRANGE", KEYS[1], -1, -1, "WITHSCORES")
+redis.call("PEXPIREAT", KEYS[1], last[2])
+return 1
+`)
+
+// redisExtendLeaseScript moves the lease expiry only if it is still held.
+var redisExtendLeaseScript = redis.NewScript(`
+local t = redis.call("TIME")
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local expireAt = redis.call("ZSCORE", KEYS[1], ARGV[2])
+if not expireAt or tonumber(expireAt) <= now then
+	return 0
+end
+redis.call("ZADD", KEYS[1], now + tonumber(ARGV[1]), ARGV[2])
+local last = redis.call("ZRANGE", KEYS[1], -1, -1, "WITHSCORES")
+redis.call("PEXPIREAT", KEYS[1], last[2])
+return 1
+`)
+
+// redisReleaseLeaseScript removes the lease & tells whether it was still held.
+var redisReleaseLeaseScript = redis.NewScript(`
+local t = redis.call("TIME")
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local expireAt = redis.call("ZSCORE", KEYS[1], ARGV[1])
+redis.call("ZREM", KEYS[1], ARGV[1])
+if not expireAt or tonumber(expireAt) <= now then
+	return 0
+end
+return 1
+`)
+
+// newRedisStore opens redis connection based on appropriate client.
+func newRedisStore(cfg *Config) (*redisStore, error) {
+	var addrs []string
+	store := &redisStore{}
+
+	// Read field "Addresses" first from config, means the firt & priority is using redis standalone
+	// or cluster mode.
+	if !qore.ValidationIsEmpty(cfg.Addresses) {
+		store.clustering = strings.Contains(cfg.Addresses, ",")
+		for addr := range strings.SplitSeq(cfg.Addresses, ",") {
+			if len(strings.TrimSpace(addr)) > 0 {
+				addrs = append(addrs, addr)
+			}
+		}
+		if len(addrs) == 0 {
+			return nil, errors.New("[cache] failed to open connection: redis.Addresses is empty")
+		}
+
+		// New client.
+		if store.clustering {
+			// Redis client cluster.
+			store.client = redis.NewClusterClient(&redis.ClusterOptions{
+				Addrs:      addrs,
+				Username:   cfg.Username,
+				Password:   cfg.Password,
+				ClientName: cfg.Namespace,
+			})
+		} else {
+			opts := &redis.Options{
+				Addr:       addrs[0],
+				ClientName: cfg.Namespace,
+				Username:   cfg.Username,
+				Password:   cfg.Password,
+				DB:         cfg.DB,
+			}
+			store.client = redis.NewClient(opts)
+			store.newClient = func(
+				protocol int,
+				onConnect func(ctx context.Context, cn *redis.Conn) error,
+			) *redis.Client {
+				clone := *opts
+				clone.Protocol, clone.OnConnect = protocol, onConnect
+				return redis.NewClient(&clone)
+			}
+		}
+		return store, nil
+	}
+
+	// If field "Addresses" empty read through field "SentinelAddresses", if any open redis using sentinel.
+	if !qore.ValidationIsEmpty(cfg.SentinelAddresses) {
+		for addr := range strings.SplitSeq(cfg.SentinelAddresses, ",") {
+			if len(strings.TrimSpace(addr)) > 0 {
+				addrs = append(addrs, addr)
+			}
+		}
+		if len(addrs) == 0 {
+			return nil, errors.New("[cache] failed to open connection: redis.SentinelAddresses is empty")
+		}
+
+		store.clustering = cfg.SentinelCluster
+		sentOpts := &redis.FailoverOptions{
+			// Sentinel.
+			MasterName:       cfg.SentinelMaster,
+			SentinelAddrs:    addrs,
+			SentinelUsername: cfg.SentinelUsername,
+			SentinelPassword: cfg.SentinelPassword,
+			ClientName:       cfg.Namespace,
+
+			// Redis.
+			Username: cfg.Username,
+			Password: cfg.Password,
+			DB:       cfg.DB,
+		}
+
+		// Sentinel cluster?.
+		if store.clustering {
+			sentOpts.RouteByLatency = true
+			store.client = redis.NewFailoverClusterClient(sentOpts)
+		} else {
+			store.client = redis.NewFailoverClient(sentOpts)
+			store.newClient = func(
+				protocol int,
+				onConnect func(ctx context.Context, cn *redis.Conn) error,
+			) *redis.Client {
+				clone := *sentOpts
+				clone.Protocol, clone.OnConnect = protocol, onConnect
+				return redis.NewFailoverClient(&clone)
+			}
+		}
+		return store, nil
+	}
+
+	// Fallback error.
+	return nil, errors.New("at least one of redis addresses and sentinel addresses must be defines")
+}
+
+// Get returns the raw value of the key, ErrCacheMiss is returned when the key does not exist.
+func (s *redisStore) Get(ctx context.Context, key string) ([]byte, error) {
+	return missing(s.client.Get(ctx, key).Bytes())
+}
+
+// Set stores the raw value of the key.
+func (s *redisStore) Set(ctx context.Context, key string, val []byte, ttl time.Duration) error {
+	return s.client.Set(ctx, key, val, ttl).Err()
+}
+
+// SetNX stores the raw value only if the key does not exist yet.
+func (s *redisStore) SetNX(ctx context.Context, key string, val []byte, ttl time.Duration) (bool, error) {
+	return s.client.SetNX(ctx, key, val, ttl).Result()
+}
+
+// Unlock deletes the lock key only if its value equals to the token.
+func (s *redisStore) Unlock(ctx context.Context, key string, token []byte) (bool, error) {
+	n, err := redisUnlockScript.Run(ctx, s.client, []string{key}, token).Int64()
+	return n > 0, err
+}
+
+// SlidingWindow runs the sliding window counter in a single script on the redis clock.
+func (s *redisStore) SlidingWindow(
+	ctx context.Context, key string, limit int64, window time.Duration,
+) (bool, int64, int64, time.Duration, error) {
+	res, err := redisSlidingWindowScript.Run(ctx, s.client, []string{key},
+		limit, max(window.Microseconds(), 1)).Int64Slice()
+	if err != nil {
+		return false, 0, 0, 0, err
+	}
+	return res[0] == 1, res[1], res[2], time.Duration(res[3]) * time.Microsecond, nil
+}
+
+// GCRA runs the generic cell rate algorithm of the key in a single script on the redis clock.
+func (s *redisStore) GCRA(ctx context.Context, key string, increment, tolerance int64) (bool, int64, int64, error) {
+	res, err := redisGCRAScript.Run(ctx, s.client, []string{key}, increment, tolerance).Int64Slice()
+	if err != nil {
+		return false, 0, 0, err
+	}
+	return res[0] == 1, res[1], res[2], nil
+}
+
+// AcquireLease adds the lease to the sorted set of the key only if there is a permit left.
+func (s *redisStore) AcquireLease(ctx context.Context, key, id string, limit int64, ttl time.Duration) (bool, error) {
+	n, err := redisAcquireLeaseScript.Run(ctx, s.client, []string{key}, limit, ttl.Milliseconds(), id).Int64()
+	return n == 1, err
+}
+
+// ExtendLease moves the lease expiry only if it is still held.
+func (s *redisStore) ExtendLease(ctx context.Context, key, id string, ttl time.Duration) (bool, error) {
+	n, err := redisExtendLeaseScript.Run(ctx, s.client, []string{key}, ttl.Milliseconds(), id).Int64()
+	return n == 1, err
+}
+
+// ReleaseLease removes the lease from the sorted set of the key.
+func (s *redisStore) ReleaseLease(ctx context.Context, key, id string) (bool, error) {
+	n, err := redisReleaseLeaseScript.Run(ctx, s.client, []string{key}, id).Int64()
+	return n == 1, err
+}
+
+// Del deletes the keys.
+func (s *redisStore) Del(ctx context.Context, keys ...string) (int64, error) {
+	return s.client.Del(ctx, keys...).Result()
+}
+
+// Exists returns the number of the given keys that exist.
+func (s *redisStore) Exists(ctx context.Context, keys ...string) (int64, error) {
+	return s.client.Exists(ctx, keys...).Result()
+}
+
+// Expire sets the time-to-live of the key, ttl <= 0 removes the expiration.
+func (s *redisStore) Expire(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	if ttl <= 0 {
+		return s.client.Persist(ctx, key).Result()
+	}
+	return s.client.Expire(ctx, key, ttl).Result()
+}
+
+// Scan returns all keys that match the pattern, in cluster mode SCAN is performed in the each master node.
+func (s *redisStore) Scan(ctx context.Context, match string) ([]string, error) {
+	var keys []string
+	if !s.clustering {
+		err := scan(ctx, s.client, match, &keys)
+		return keys, err
+	}
+
+	clusterClient, ok := s.client.(*redis.ClusterClient)
+	if !ok {
+		return nil, ErrClientNotCluster
+	}
+	var mu sync.Mutex
+	err := clusterClient.ForEachMaster(ctx, func(ctx context.Context, client *redis.Client) error {
+		var founds []string
+		if err := scan(ctx, client, match, &founds); err != nil {
+			return err
+		}
+		mu.Lock()
+		keys = append(keys, founds...)
+		mu.Unlock()
+		return nil
+	})
+	return keys, err
+}
+
+// MGet returns the raw values of the keys. In cluster mode the keys are grouped by hash slot
+// and every group is read by its own MGET in a single pipeline, since MGET fails on cross-slot keys.
+func (s *redisStore) MGet(ctx context.Context, keys ...string) ([][]byte, error) {
+	vals := make([][]byte, len(keys))
+	if len(keys) == 0 {
+		return vals, nil
+	}
+	if !s.clustering {
+		res, err := s.client.MGet(ctx, keys...).Result()
+		if err != nil {
+			return nil, err
+		}
+		fillMGet(vals, res, nil)
+		return vals, nil
+	}
+
+	// Group keys index by slot.
+	slots := make(map[uint16][]int)
+	for idx, key := range keys {
+		slot := keySlot(key)
+		slots[slot] = append(slots[slot], idx)
+	}
+	groups := make([][]int, 0, len(slots))
+	cmds := make([]*redis.SliceCmd, 0, len(slots))
+	_, err := s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, idxs := range slots {
+			groupKeys := make([]string, len(idxs))
+			for n, idx := range idxs {
+				groupKeys[n] = keys[idx]
+			}
+			groups = append(groups, idxs)
+			cmds = append(cmds, pipe.MGet(ctx, groupKeys...))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for n, cmd := range cmds {
+		fillMGet(vals, cmd.Val(), groups[n])
+	}
+	return vals, nil
+}
+
+// MSet stores the raw values of the items in a single pipeline of SET commands. In cluster mode
+// the pipeline is split per node by the hash slot of every key by go-redis.
+func (s *redisStore) MSet(ctx context.Context, items []StoreItem) []error {
+	errs := make([]error, len(items))
+	if len(items) == 0 {
+		return errs
+	}
+	cmds := make([]*redis.StatusCmd, len(items))
+	s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for idx, item := range items {
+			cmds[idx] = pipe.Set(ctx, item.Key, item.Val, item.TTL)
+		}
+		return nil
+	})
+	for idx, cmd := range cmds {
+		errs[idx] = cmd.Err()
+	}
+	return errs
+}
+
+// fillMGet puts MGET result into vals, idxs maps the result position to the vals position (nil is identity).
+func fillMGet(vals [][]byte, res []any, idxs []int) {
+	for n, v := range res {
+		idx := n
+		if idxs != nil {
+			idx = idxs[n]
+		}
+		switch v := v.(type) {
+		case string:
+			vals[idx] = []byte(v)
+		case []byte:
+			vals[idx] = v
+		}
+	}
+}
+
+// Ping checks the redis availability.
+func (s *redisStore) Ping(ctx context.Context) (string, error) {
+	return s.client.Ping(ctx).Result()
+}
+
+// Close closes the redis client.
+func (s *redisStore) Close() error {
+	s.swapTracked(nil)
+	return s.client.Close()
+}
+
+// Publish sends the payload to the channel subscribers.
+func (s *redisStore) Publish(ctx context.Context, channel string, payload []byte) error {
+	return s.client.Publish(ctx, channel, payload).Err()
+}
+
+// Subscribe blocks & receives the channel messages until ctx is done.
+// The dropped subscription is re-established by go-redis on the next receive.
+func (s *redisStore) Subscribe(
+	ctx context.Context,
+	channel string,
+	onMessage func(payload []byte),
+	onReset func(),
+) error {
+	ps := s.client.Subscribe(ctx, channel)
+	receive(ctx, ps, func(m *redis.Message) {
+		onMessage([]byte(m.Payload))
+	}, onReset, onReset)
+	return nil
+}
+
+// track enables redis client-side caching, the keys read through trackedGet (or all keys with the prefix
+// in BCAST mode) are tracked by the server, and the server pushes the invalidation through a dedicated
+// RESP2 connection subscribed to the "__redis__:invalidate" channel. It blocks until ctx is done.
+// The tracked reads client uses RESP2 as well, so no push message is interleaved with the replies.
+//
+// Whenever the invalidation connection is (re)established the tracked reads client is recreated to
+// redirect to the new connection, and onReset is called since the invalidation may be lost.
+func (s *redisStore) track(
+	ctx context.Context,
+	bcastPrefix string,
+	onInvalidate func(keys []string),
+	onReset func(),
+) error {
+	if s.newClient == nil {
+		return ErrTrackingNotSupported
+	}
+
+	// Capture the client ID of the invalidation connection every time it connects.
+	var redirect atomic.Int64
+	invClient := s.newClient(2, func(ctx context.Context, cn *redis.Conn) error {
+		id, err := cn.ClientID(ctx).Result()
+		if err != nil {
+			return err
+		}
+		redirect.Store(id)
+		return nil
+	})
+	defer func() {
+		s.swapTracked(nil)
+		invClient.Close()
+	}()
+
+	ps := invClient.Subscribe(ctx, redisInvalidateChannel)
+	receive(ctx, ps, func(m *redis.Message) {
+		switch {
+		case len(m.PayloadSlice) > 0:
+			onInvalidate(m.PayloadSlice)
+		case m.Payload != "":
+			onInvalidate([]string{m.Payload})
+		default:
+			// Null payload means the server flushed the whole database.
+			onReset()
+		}
+	}, func() {
+		id := redirect.Load()
+		s.swapTracked(s.newClient(2, func(ctx context.Context, cn *redis.Conn) error {
+			args := []any{"CLIENT", "TRACKING", "ON", "REDIRECT", id}
+			if bcastPrefix != "" {
+				args = append(args, "BCAST", "PREFIX", bcastPrefix)
+			}
+			return cn.Do(ctx, args...).Err()
+		}))
+		onReset()
+	}, func() {
+		s.swapTracked(nil)
+		onReset()
+	})
+	return nil
+}
+
+// swapTracked replaces & closes the previous tracked reads client.
+func (s *redisStore) swapTracked(client *redis.Client) {
+	s.trackedMu.Lock()
+	prev := s.tracked
+	s.tracked = client
+	s.trackedMu.Unlock()
+	if prev != nil {
+		prev.Close()
+	}
+}
+
+// trackedGet reads the key through the tracked reads client, tracked is false if the read is not tracked
+// by the server (e.g. the invalidation connection is not ready), so the value must not be kept locally.
+func (s *redisStore) trackedGet(ctx context.Context, key string) (val []byte, tracked bool, err error) {
+	s.trackedMu.RLock()
+	client := s.tracked
+	s.trackedMu.RUnlock()
+	if client != nil {
+		val, err = missing(client.Get(ctx, key).Bytes())
+		if !errors.Is(err, redis.ErrClosed) {
+			return val, err == nil, err
+		}
+	}
+	val, err = s.Get(ctx, key)
+	return val, false, err
+}
+
+// receive blocks & dispatches the subscription messages until ctx is done, the message content is empty
+// when it is unknown. onSubscribe is called every time the subscription is (re)established and onDrop is called
+// when the subscription is dropped, go-redis re-establishes it on the next receive.
+func receive(
+	ctx context.Context,
+	ps *redis.PubSub,
+	onMessage func(m *redis.Message),
+	onSubscribe func(),
+	onDrop func(),
+) {
+	stop := context.AfterFunc(ctx, func() { ps.Close() })
+	defer func() {
+		stop()
+		ps.Close()
+	}()
+
+	for {
+		msg, err := ps.ReceiveTimeout(ctx, redisSubscribeHealthCheck)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			// Idle subscription, make sure the connection still alive.
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				if err := ps.Ping(ctx); err == nil {
+					continue
+				}
+			}
+
+			// Unparseable message (e.g. null invalidation payload), the connection is still fine
+			// so pass it as an empty message.
+			if !isConnError(err) {
+				onMessage(&redis.Message{})
+				continue
+			}
+
+			// Dropped subscription, messages may be lost.
+			onDrop()
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(redisSubscribeBackoff):
+			}
+			continue
+		}
+
+		switch m := msg.(type) {
+		case *redis.Subscription:
+			if m.Kind == "subscribe" {
+				onSubscribe()
+			}
+		case *redis.Message:
+			onMessage(m)
+		}
+	}
+}
+
+// keySlot returns the redis cluster hash slot of the key, the hash tag "{...}" is respected.
+func keySlot(key string) uint16 {
+	if start := strings.IndexByte(key, '{'); start >= 0 {
+		if end := strings.IndexByte(key[start+1:], '}'); end > 0 {
+			key = key[start+1 : start+1+end]
+		}
+	}
+	var crc uint16
+	for n := 0; n < len(key); n++ {
+		crc ^= uint16(key[n]) << 8
+		for range 8 {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc % 16384
+}
+
+// missing translates redis.Nil of the read reply to ErrCacheMiss.
+func missing(val []byte, err error) ([]byte, error) {
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrCacheMiss
+	}
+	return val, err
+}
+
+// isConnError reports whether the error is caused by the connection, not by the reply.
+func isConnError(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, redis.ErrClosed)
+}
+
+func scan(ctx context.Context, rdb redis.UniversalClient, match string, founds *[]string) error {
+	iter := rdb.Scan(ctx, 0, match, 0).Iterator()
+	for iter.Next(ctx) {
+		*founds = append(*founds, iter.Val())
+	}
+	if iter.Err() != nil {
+		return iter.Err()
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/qoinlyid/qore"
+	"github.com/stretchr/testify/assert"
+)
+
+// newRedisTest returns an opened redis instance of ./.env, the test is skipped when redis is not available.
+func newRedisTest(t *testing.T) *Instance {
+	t.Helper()
+	t.Setenv(qore.CONFIG_USED_KEY, "./.env")
+	i := New()
+	if err := i.Open(); err != nil {
+		t.Skipf("Redis is not available: %s", err)
+	}
+	t.Cleanup(func() { i.Close() })
+	if _, err := i.store.Ping(t.Context()); err != nil {
+		t.Skipf("Redis is not available: %s", err)
+	}
+	return i
+}
+
+func TestRedisStoreSetGet(t *testing.T) {
+	t.Setenv(qore.CONFIG_USED_KEY, "./.env")
+	store, err := newRedisStore(New().cfg)
+	assert.NoError(t, err, "Open redis store must be no error")
+	defer store.Close()
+
+	key := testPrefix + DefaultKeySeparator + "TestRedisStore"
+	err = store.Set(t.Context(), key, []byte(testValue), time.Minute)
+	assert.NoError(t, err, "Set must be no error")
+	b, err := store.Get(t.Context(), key)
+	assert.NoError(t, err, "Get must be no error")
+	assert.Equal(t, testValue, string(b), "Value must be equal to the stored value")
+
+	count, err := store.Del(t.Context(), key)
+	assert.NoError(t, err, "Del must be no error")
+	assert.Equal(t, int64(1), count, "Deleted count must be 1")
+	_, err = store.Get(t.Context(), key)
+	assert.ErrorIs(t, err, ErrCacheMiss, "Get deleted key must be ErrCacheMiss")
+}
+
+func TestRedisStoreSetNX(t *testing.T) {
+	t.Setenv(qore.CONFIG_USED_KEY, "./.env")
+	store, err := newRedisStore(New().cfg)
+	assert.NoError(t, err, "Open redis store must be no error")
+	defer store.Close()
+
+	key := testPrefix + DefaultKeySeparator + "TestRedisStoreSetNX"
+	defer store.Del(t.Context(), key)
+	ok, err := store.SetNX(t.Context(), key, []byte("1"), time.Minute)
+	assert.NoError(t, err, "SetNX must be no error")
+	assert.True(t, ok, "SetNX must be stored for the first time")
+	ok, err = store.SetNX(t.Context(), key, []byte("1"), time.Minute)
+	assert.NoError(t, err, "SetNX must be no error")
+	assert.False(t, ok, "SetNX must not be stored for the second time")
+}
+
+func TestRedisStoreUnlock(t *testing.T) {
+	t.Setenv(qore.CONFIG_USED_KEY, "./.env")
+	store, err := newRedisStore(New().cfg)
+	assert.NoError(t, err, "Open redis store must be no error")
+	defer store.Close()
+
+	key := testPrefix + DefaultKeySeparator + "TestRedisStoreUnlock"
+	defer store.Del(t.Context(), key)
+	store.Set(t.Context(), key, []byte("a"), time.Minute)
+	ok, err := store.Unlock(t.Context(), key, []byte("b"))
+	assert.NoError(t, err, "Unlock must be no error")
+	assert.False(t, ok, "Lock of the other token must not be released")
+	ok, err = store.Unlock(t.Context(), key, []byte("a"))
+	assert.NoError(t, err, "Unlock must be no error")
+	assert.True(t, ok, "Lock of the token must be released")
+}
+
+func TestRedisStoreNoAddresses(t *testing.T) {
+	_, err := newRedisStore(&Config{})
+	assert.Error(t, err, "Open redis store without addresses must be error")
+}
+
+func TestKeySlot(t *testing.T) {
+	assert.Equal(t, uint16(12739), keySlot("123456789"), "Slot must be CRC16 of the key mod 16384")
+	assert.Equal(t, keySlot("user1000"), keySlot("{user1000}.following"), "Hash tag must be respected")
+}
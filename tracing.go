@@ -0,0 +1,111 @@
+package cache
+
+import (
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName is the instrumentation scope of the cache spans.
+const tracerName = "github.com/qoinlyid/cache"
+
+// TracingOptions defines the OpenTelemetry spans of the cache operations.
+type TracingOptions struct {
+	// TracerProvider creates the tracer. Default is the global otel.GetTracerProvider().
+	TracerProvider trace.TracerProvider
+
+	// RawKey records the raw key as the "cache.key" attribute, only the key prefix is recorded by default.
+	RawKey bool
+}
+
+// tracing holds the tracer of the instance.
+type tracing struct {
+	tracer trace.Tracer
+	rawKey bool
+}
+
+// SetTracing enables the OpenTelemetry spans of every Put, Pull, Remember, Perform, Has, GetAllKeys
+// & the batch operations. Remember nests a child span for the loader call.
+//
+//	cache := cache.New().SetTracing(cache.TracingOptions{TracerProvider: tp})
+func (i *Instance) SetTracing(opts TracingOptions) *Instance {
+	tp := opts.TracerProvider
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	i.tracing = &tracing{tracer: tp.Tracer(tracerName), rawKey: opts.RawKey}
+	return i
+}
+
+// startSpan starts the span of the operation, nil span is returned when tracing is disabled.
+func (i *Instance) startSpan(ctx context.Context, op *operation) (context.Context, trace.Span) {
+	if i.tracing == nil {
+		return ctx, nil
+	}
+	name := "cache." + op.name
+	if op.remember {
+		name = "cache.remember"
+	}
+	attrs := []attribute.KeyValue{
+		attribute.String("db.system.name", i.cfg.Driver),
+		attribute.String("cache.namespace", i.cfg.Namespace),
+		attribute.String("cache.op", op.name),
+	}
+	if op.prefix != "" {
+		attrs = append(attrs, attribute.String("cache.prefix", op.prefix))
+	}
+	if i.tracing.rawKey && op.key != "" {
+		attrs = append(attrs, attribute.String("cache.key", op.key))
+	}
+	return i.tracing.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+// endSpan records the measurements & the error of the operation then ends its span.
+func (i *Instance) endSpan(span trace.Span, op *operation, err error) {
+	if span == nil {
+		return
+	}
+	switch op.name {
+	case OpGet, OpHas:
+		if op.hits+op.misses == 1 {
+			span.SetAttributes(attribute.Bool("cache.hit", op.hits == 1))
+		} else {
+			span.SetAttributes(attribute.Int("cache.hits", op.hits), attribute.Int("cache.misses", op.misses))
+		}
+	}
+	if op.ttl > 0 {
+		span.SetAttributes(attribute.Float64("cache.ttl_seconds", op.ttl.Seconds()))
+	}
+	if op.size > 0 {
+		span.SetAttributes(attribute.Int("cache.size", op.size))
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		span.SetAttributes(attribute.String("error.type", ErrorType(err)))
+	}
+	span.End()
+}
+
+// callLoader calls the Remember loader within its child span (if tracing is enabled),
+// the loader receives the context of the span.
+func (i *Instance) callLoader(ctx context.Context, rem RememberContextFn) (bool, any, error) {
+	if i.tracing == nil {
+		return rem(ctx)
+	}
+	ctx, span := i.tracing.tracer.Start(ctx, "cache.remember.loader")
+	defer span.End()
+	forever, val, err := rem(ctx)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		span.SetAttributes(attribute.Bool("cache.not_found", true))
+	case err != nil:
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return forever, val, err
+}
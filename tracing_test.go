@@ -0,0 +1,142 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// newTracingTest returns an opened memory instance with tracing recorded by the in-memory exporter.
+func newTracingTest(t *testing.T, rawKey bool) (*Instance, *tracetest.InMemoryExporter) {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	t.Cleanup(func() { tp.Shutdown(t.Context()) })
+	return newMemoryTest(t).SetTracing(TracingOptions{TracerProvider: tp, RawKey: rawKey}), exporter
+}
+
+// spanAttr returns the value of the span attribute.
+func spanAttr(span tracetest.SpanStub, key string) (attribute.Value, bool) {
+	for _, attr := range span.Attributes {
+		if string(attr.Key) == key {
+			return attr.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestTracingSpans(t *testing.T) {
+	i, exporter := newTracingTest(t, false)
+	ctx := t.Context()
+	_, err := i.Set(ctx, testKey).SetPrefix(testPrefix).SetTTL(time.Minute).Put(testValue)
+	assert.NoError(t, err, "Put must be no error")
+	var out string
+	i.Get(ctx, testKey, testPrefix).Pull(&out)
+	i.Get(ctx, "missing", testPrefix).Pull(&out)
+	i.Has(ctx, testKey, testPrefix)
+	i.GetAllKeys(ctx, testPrefix)
+	i.Delete(ctx, testKey, testPrefix).Perform()
+
+	spans := exporter.GetSpans()
+	names := make([]string, len(spans))
+	for n, span := range spans {
+		names[n] = span.Name
+		prefix, _ := spanAttr(span, "cache.prefix")
+		assert.Equal(t, testPrefix, prefix.AsString(), "Span must carry the key prefix")
+		_, ok := spanAttr(span, "cache.key")
+		assert.False(t, ok, "Span must not carry the raw key by default")
+	}
+	assert.Equal(t, []string{"cache.set", "cache.get", "cache.get", "cache.has", "cache.scan", "cache.del"}, names, "Every operation must create a span")
+
+	ttl, _ := spanAttr(spans[0], "cache.ttl_seconds")
+	assert.Equal(t, float64(60), ttl.AsFloat64(), "Set span must carry the TTL")
+	size, _ := spanAttr(spans[0], "cache.size")
+	assert.Equal(t, int64(len(testValue)), size.AsInt64(), "Set span must carry the encoded size")
+	hit, _ := spanAttr(spans[1], "cache.hit")
+	assert.True(t, hit.AsBool(), "Get span must be hit")
+	hit, _ = spanAttr(spans[2], "cache.hit")
+	assert.False(t, hit.AsBool(), "Get span must be miss")
+	assert.Equal(t, codes.Unset, spans[2].Status.Code, "Miss must not be error")
+}
+
+func TestTracingRawKey(t *testing.T) {
+	i, exporter := newTracingTest(t, true)
+	i.Set(t.Context(), testKey).SetPrefix(testPrefix).Put(testValue)
+
+	key, _ := spanAttr(exporter.GetSpans()[0], "cache.key")
+	assert.Equal(t, testPrefix+DefaultKeySeparator+testKey, key.AsString(), "Span must carry the raw key")
+}
+
+func TestTracingRemember(t *testing.T) {
+	i, exporter := newTracingTest(t, false)
+	var out int
+	err := i.Get(t.Context(), testKeyRemember).SetTTL(time.Minute).Remember(&out, func() (bool, any, error) {
+		return false, testValueRemember, nil
+	})
+	assert.NoError(t, err, "Remember must be no error")
+
+	spans := exporter.GetSpans()
+	byName := make(map[string]tracetest.SpanStub, len(spans))
+	for _, span := range spans {
+		byName[span.Name] = span
+	}
+	remember, loader, set := byName["cache.remember"], byName["cache.remember.loader"], byName["cache.set"]
+	assert.True(t, remember.SpanContext.IsValid(), "Remember must create a span")
+	assert.Equal(t, remember.SpanContext.SpanID(), loader.Parent.SpanID(), "Loader span must be child of the remember span")
+	assert.Equal(t, remember.SpanContext.SpanID(), set.Parent.SpanID(), "Set span must be child of the remember span")
+	hit, _ := spanAttr(remember, "cache.hit")
+	assert.False(t, hit.AsBool(), "Remember span must be miss")
+}
+
+func TestTracingRememberContext(t *testing.T) {
+	i, exporter := newTracingTest(t, false)
+	loaderSpan := func(name string) trace.SpanContext {
+		for _, span := range exporter.GetSpans() {
+			if span.Name == "cache.remember.loader" {
+				return span.SpanContext
+			}
+		}
+		t.Fatalf("%s loader span must be recorded", name)
+		return trace.SpanContext{}
+	}
+
+	var out int
+	var got trace.SpanContext
+	err := i.Get(t.Context(), testKeyRemember).RememberContext(&out, func(ctx context.Context) (bool, any, error) {
+		got = trace.SpanContextFromContext(ctx)
+		return false, testValueRemember, nil
+	})
+	assert.NoError(t, err, "RememberContext must be no error")
+	assert.Equal(t, loaderSpan("Untyped").SpanID(), got.SpanID(), "Loader must receive the loader span")
+
+	exporter.Reset()
+	users := For[int](i, testPrefix)
+	_, err = users.Remember(t.Context(), testKey, func(ctx context.Context) (int, error) {
+		got = trace.SpanContextFromContext(ctx)
+		return testValueRemember, nil
+	})
+	assert.NoError(t, err, "Typed Remember must be no error")
+	assert.Equal(t, loaderSpan("Typed").SpanID(), got.SpanID(), "Typed loader must receive the loader span")
+}
+
+func TestTracingError(t *testing.T) {
+	i, exporter := newTracingTest(t, false)
+	var out int
+	loaderErr := errors.New("db down")
+	err := i.Get(t.Context(), testKeyRemember).Remember(&out, func() (bool, any, error) {
+		return false, nil, loaderErr
+	})
+	assert.ErrorIs(t, err, loaderErr, "Remember must return the loader error")
+
+	for _, span := range exporter.GetSpans() {
+		assert.Equal(t, codes.Error, span.Status.Code, "Span of "+span.Name+" must be error")
+	}
+}
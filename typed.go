@@ -0,0 +1,141 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Typed is a type-safe layer of the Instance for the values of type T,
+// so the type mismatch is caught at compile time instead of at runtime.
+type Typed[T any] struct {
+	inst    *Instance
+	prefix  string
+	ttl     time.Duration
+	codec   Codec
+	version uint32
+}
+
+// For creates typed cache of T for the given instance & key prefix.
+// The prefix is optional, use an empty string to store the keys right under the namespace.
+//
+//	users := cache.For[User](inst, "user")
+//	user, found, err := users.Get(ctx, "123")
+func For[T any](inst *Instance, prefix string) *Typed[T] {
+	return &Typed[T]{inst: inst, prefix: prefix}
+}
+
+// WithTTL returns a copy of the typed cache with the given default time-to-live,
+// it is used by Remember and by Set when the TTL argument is <= 0.
+// If TTL is <= 0, the default value will be used is 1 minutes.
+//
+//	users := cache.For[User](inst, "user").WithTTL(time.Hour)
+func (t *Typed[T]) WithTTL(ttl time.Duration) *Typed[T] {
+	clone := *t
+	clone.ttl = ttl
+	return &clone
+}
+
+// WithCodec returns a copy of the typed cache with the given codec, it overrides the instance codec.
+//
+//	users := cache.For[User](inst, "user").WithCodec(cache.JSONCodec)
+func (t *Typed[T]) WithCodec(codec Codec) *Typed[T] {
+	clone := *t
+	clone.codec = codec
+	return &clone
+}
+
+// WithVersion returns a copy of the typed cache with the given schema version, it overrides the config
+// SchemaVersion. Bump it when T changes so the values of the old shape are read as misses.
+//
+//	users := cache.For[User](inst, "user").WithVersion(2)
+func (t *Typed[T]) WithVersion(version uint32) *Typed[T] {
+	clone := *t
+	clone.version = version
+	return &clone
+}
+
+// Get retrieves the value of the key. The found is false with nil error when the key does not exist.
+//
+//	user, found, err := users.Get(ctx, "123")
+func (t *Typed[T]) Get(ctx context.Context, key string) (val T, found bool, err error) {
+	err = t.inst.Get(ctx, key, t.prefix).SetCodec(t.codec).SetVersion(t.version).Pull(&val)
+	if errors.Is(err, ErrCacheMiss) {
+		return val, false, nil
+	}
+	if err != nil {
+		return val, false, err
+	}
+	return val, true, nil
+}
+
+// GetMany retrieves the values of the keys in a single round trip (per hash slot in cluster mode).
+// The missing keys are reported separately, and err joins the backend error or the per-key decode errors.
+//
+//	users, misses, err := users.GetMany(ctx, []string{"1", "2", "3"})
+func (t *Typed[T]) GetMany(ctx context.Context, keys []string) (vals map[string]T, misses []string, err error) {
+	vals = make(map[string]T, len(keys))
+	res, err := t.inst.GetMany(ctx, keys, t.prefix).SetCodec(t.codec).SetVersion(t.version).Pull(&vals)
+	if err != nil {
+		return nil, nil, err
+	}
+	return vals, res.Misses, res.Err()
+}
+
+// Set stores the value of the key, ttl <= 0 means the typed cache default TTL is used.
+//
+//	err := users.Set(ctx, "123", user, 10*time.Minute)
+func (t *Typed[T]) Set(ctx context.Context, key string, val T, ttl time.Duration) error {
+	if ttl <= 0 {
+		ttl = t.ttl
+	}
+	_, err := t.inst.Set(ctx, key).SetPrefix(t.prefix).SetTTL(ttl).SetCodec(t.codec).SetVersion(t.version).Put(val)
+	return err
+}
+
+// SetMany stores the values of the keys in a single pipeline, ttl <= 0 means the typed cache default TTL
+// is used. The returned error joins the per-key errors.
+//
+//	err := users.SetMany(ctx, map[string]User{"1": user1, "2": user2}, time.Hour)
+func (t *Typed[T]) SetMany(ctx context.Context, vals map[string]T, ttl time.Duration) error {
+	if ttl <= 0 {
+		ttl = t.ttl
+	}
+	items := make([]Item, 0, len(vals))
+	for key, val := range vals {
+		items = append(items, Item{Key: key, Value: val})
+	}
+	_, err := t.inst.SetMany(ctx, t.prefix).SetTTL(ttl).SetCodec(t.codec).SetVersion(t.version).PutMany(items...)
+	return err
+}
+
+// SetForever stores the value of the key without an expiration time.
+//
+//	err := users.SetForever(ctx, "123", user)
+func (t *Typed[T]) SetForever(ctx context.Context, key string, val T) error {
+	_, err := t.inst.Set(ctx, key).SetPrefix(t.prefix).SetCodec(t.codec).SetVersion(t.version).PutForever(val)
+	return err
+}
+
+// Remember retrieves the value of the key, or calls the loader & stores its value with
+// the typed cache default TTL when the key does not exist.
+//
+//	user, err := users.Remember(ctx, "123", func(ctx context.Context) (User, error) {
+//		return db.GetUser(ctx, 123)
+//	})
+func (t *Typed[T]) Remember(ctx context.Context, key string, fn func(ctx context.Context) (T, error)) (T, error) {
+	var val T
+	err := t.inst.Get(ctx, key, t.prefix).SetTTL(t.ttl).SetCodec(t.codec).SetVersion(t.version).RememberContext(&val, func(ctx context.Context) (bool, any, error) {
+		v, err := fn(ctx)
+		return false, v, err
+	})
+	return val, err
+}
+
+// Delete deletes the key, it returns true if the key existed.
+//
+//	deleted, err := users.Delete(ctx, "123")
+func (t *Typed[T]) Delete(ctx context.Context, key string) (bool, error) {
+	count, err := t.inst.Delete(ctx, key, t.prefix).Perform()
+	return count > 0, err
+}
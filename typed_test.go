@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type typedUser struct {
+	ID   int
+	Name string
+}
+
+func TestTypedSetGet(t *testing.T) {
+	i := newMemoryTest(t)
+	users := For[typedUser](i, testPrefix)
+
+	err := users.Set(t.Context(), "1", typedUser{ID: 1, Name: "John"}, time.Minute)
+	assert.NoError(t, err, "Set must be no error")
+
+	user, found, err := users.Get(t.Context(), "1")
+	assert.NoError(t, err, "Get must be no error")
+	assert.True(t, found, "Get must be found")
+	assert.Equal(t, typedUser{ID: 1, Name: "John"}, user, "Value must be equal to the stored value")
+}
+
+func TestTypedGetMiss(t *testing.T) {
+	i := newMemoryTest(t)
+	users := For[typedUser](i, testPrefix)
+
+	user, found, err := users.Get(t.Context(), "missing")
+	assert.NoError(t, err, "Get missing key must be no error")
+	assert.False(t, found, "Get missing key must be not found")
+	assert.Zero(t, user, "Value must be zero")
+}
+
+func TestTypedRemember(t *testing.T) {
+	i := newMemoryTest(t)
+	users := For[typedUser](i, testPrefix).WithTTL(time.Minute)
+
+	var calls int
+	loader := func(ctx context.Context) (typedUser, error) {
+		calls++
+		return typedUser{ID: 2, Name: "Jane"}, nil
+	}
+	for range 2 {
+		user, err := users.Remember(t.Context(), "2", loader)
+		assert.NoError(t, err, "Remember must be no error")
+		assert.Equal(t, typedUser{ID: 2, Name: "Jane"}, user, "Value must be equal to the loaded value")
+	}
+	assert.Equal(t, 1, calls, "Loader must be called once")
+}
+
+func TestTypedRememberError(t *testing.T) {
+	i := newMemoryTest(t)
+	users := For[typedUser](i, testPrefix)
+
+	_, err := users.Remember(t.Context(), "3", func(ctx context.Context) (typedUser, error) {
+		return typedUser{}, errors.New("loader error")
+	})
+	assert.Error(t, err, "Remember must return the loader error")
+	_, found, _ := users.Get(t.Context(), "3")
+	assert.False(t, found, "Failed loader must not store the value")
+}
+
+func TestTypedDelete(t *testing.T) {
+	i := newMemoryTest(t)
+	counters := For[int](i, testPrefix)
+
+	counters.SetForever(t.Context(), "counter", 42)
+	deleted, err := counters.Delete(t.Context(), "counter")
+	assert.NoError(t, err, "Delete must be no error")
+	assert.True(t, deleted, "Delete existing key must be true")
+	_, found, _ := counters.Get(t.Context(), "counter")
+	assert.False(t, found, "Deleted key must be not found")
+}